@@ -8,13 +8,19 @@ import (
 	"practice/config"
 	"practice/env"
 	"practice/internal"
+	resumableRouter "practice/internal/resumable/router"
+	"practice/pkg/bus"
 	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/outbox"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func init() {
 	config.LoadEnv()
 	env.GetEnv()
@@ -31,27 +37,77 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// leaves room for the form fields sent along with uploads
+	bodyLimit := int(env.UploadMaxRequestSize) + 1<<20
+
 	app := fiber.New(fiber.Config{
 		IdleTimeout:  time.Second * 5,
 		WriteTimeout: time.Second * 5,
 		ReadTimeout:  time.Second * 5,
+		BodyLimit:    bodyLimit,
+		// resumable uploads write chunks as they arrive, every other
+		// request is read whole by BufferBody
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
+	app.Use(middleware.BufferBody(bodyLimit, resumableRouter.Streams))
 
 	port := fmt.Sprintf("0.0.0.0:%d", env.Port)
 
 	db := config.NewDB(ctx, logger)
 	defer db.Close()
 
-	internal.MainRoutes(app, db, logger)
+	event := bus.NewEventBus()
+	relay := outbox.NewRelay(db.Instance(), event, logger, outbox.DefaultOptions())
+	blob := config.NewStorage(logger)
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	internal.MainRoutes(workerCtx, app, db, logger, event, relay, blob)
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Hello World!")
 	})
 
-	logger.Fatal(app.Listen(port).Error())
-	logger.Info("Server running on port", port)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(workerCtx)
+	}()
+
+	go func() {
+		logger.Info("Server running on port", "port", port)
+		if err := app.Listen(port); err != nil {
+			logger.Fatal(err.Error())
+		}
+	}()
 
 	<-ctx.Done()
 	logger.Warn("Server is shutting down...")
-	app.Shutdown()
+
+	// stopping the workers first also ends open event streams, which the
+	// server would otherwise wait for; undelivered events stay in the
+	// outbox for the next start
+	stopWorkers()
+	<-relayDone
+
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		logger.Error(err.Error())
+	}
+
+	// no more events come from requests or the outbox, so drain the bus
+
+	drained := make(chan struct{})
+	go func() {
+		event.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(shutdownTimeout):
+		logger.Warn("Timed out waiting for events to be handled")
+	}
+	event.Close()
 }
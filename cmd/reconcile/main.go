@@ -0,0 +1,58 @@
+// Command reconcile brings the blob store in line with the uploads table
+// once: it deletes orphaned uploads and files nothing references, records
+// referenced files that have no upload yet and forgets uploads whose file is
+// gone. The server does the same every UPLOAD_SWEEP_INTERVAL.
+//
+//	go run ./cmd/reconcile -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"practice/config"
+	"practice/env"
+	fileRepository "practice/internal/file/repository"
+	fileUsecase "practice/internal/file/usecase"
+	"practice/pkg/logger"
+	"practice/pkg/storage"
+	"syscall"
+)
+
+func init() {
+	config.LoadEnv()
+	env.GetEnv()
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would change without changing anything")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg := logger.DefaultConfig()
+	logger, err := logger.NewLogger(cfg, "")
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	defer logger.Sync()
+
+	db := config.NewDB(ctx, logger)
+	defer db.Close()
+
+	repo := fileRepository.NewFileRepo(db.Instance())
+	blob := storage.NewMetered(config.NewStorage(logger), repo, int64(env.StorageQuota))
+	usecase := fileUsecase.NewFileUsecase(repo, blob, int64(env.StorageQuota), nil, logger)
+
+	report, err := usecase.Reconcile(ctx, *dryRun)
+	if err != nil {
+		logger.Fatal("reconcile failed", "error", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
@@ -7,6 +7,7 @@ import (
 	"practice/env"
 	"practice/models"
 	"practice/pkg/logger"
+	"practice/pkg/outbox"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -28,15 +29,33 @@ func NewDB(ctx context.Context, logger *logger.Logger) *DB {
 		logger.Fatal("failed to connect database: %v", err)
 	}
 
+	if err := migrateFiles(db); err != nil {
+		logger.Fatal("failed to migrate files", "error", err)
+	}
+
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Todo{},
+		&models.Comment{},
+		&models.TodoActivity{},
+		&models.TodoTemplate{},
+		&models.CalendarFeed{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Upload{},
+		&models.Attachment{},
+		&models.ResumableUpload{},
+		&outbox.Message{},
 	)
 
 	if err != nil {
 		logger.Fatal("failed to migrate database: %v", err)
 	}
 
+	if err := migrateTodos(db); err != nil {
+		logger.Fatal("failed to migrate todos", "error", err)
+	}
+
 	logger.Info("✅ Database connected! Host: %s Port: %d DB: %s", env.DBHost, env.DBPort, env.DBName)
 
 	return &DB{ctx: ctx, db: db}
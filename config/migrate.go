@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"practice/env"
+	"practice/pkg/search"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrateFiles drops the unique indexes on file keys, which files shared
+// by content no longer have. It runs before AutoMigrate, which creates the
+// indexes replacing them but never drops one.
+func migrateFiles(db *gorm.DB) error {
+	return db.Exec(`DROP INDEX IF EXISTS idx_uploads_key, idx_attachments_key`).Error
+}
+
+// migrateTodos applies the todo schema changes AutoMigrate cannot express.
+func migrateTodos(db *gorm.DB) error {
+	// give todos created before manual ordering existed a position that
+	// keeps their creation order
+	err := db.Exec(`
+		UPDATE todos SET position = ranked.position
+		FROM (
+			SELECT id, lpad(row_number() OVER (PARTITION BY user_id ORDER BY created_at)::text, 10, '0') || 'V' AS position
+			FROM todos WHERE position IS NULL OR position = ''
+		) ranked
+		WHERE todos.id = ranked.id`).Error
+	if err != nil {
+		return err
+	}
+
+	// derive the status of todos created before it was stored; the time of
+	// completion is unknown, so the last update stands in for it
+	err = db.Exec(`
+		UPDATE todos SET status = derived.status,
+			completed_at = CASE WHEN derived.status = 'done' THEN todos.updated_at END
+		FROM (
+			SELECT id, CASE
+				WHEN cardinality(todo) > 0 AND todo <@ coalesce("check", '{}') THEN 'done'
+				WHEN todo && coalesce("check", '{}') THEN 'in_progress'
+				ELSE 'open'
+			END AS status
+			FROM todos WHERE completed_at IS NULL
+		) derived
+		WHERE todos.id = derived.id AND todos.status IS DISTINCT FROM derived.status`).Error
+	if err != nil {
+		return err
+	}
+
+	return migrateTodoSearch(db)
+}
+
+// migrateTodoSearch maintains the generated search_vector column over the
+// title and checklist items, indexed with GIN. The column is rebuilt when
+// SEARCH_LANGUAGE changes.
+func migrateTodoSearch(db *gorm.DB) error {
+	lang := env.SearchLanguage
+	if !search.IsLanguage(lang) {
+		return fmt.Errorf("unsupported SEARCH_LANGUAGE %q", lang)
+	}
+
+	// array_to_string is only STABLE, so wrap it to use it in a generated column
+	err := db.Exec(`
+		CREATE OR REPLACE FUNCTION todo_search_text(title text, items text[]) RETURNS text
+		LANGUAGE sql IMMUTABLE AS $$
+			SELECT coalesce(title, '') || ' ' || coalesce(array_to_string(items, ' '), '')
+		$$`).Error
+	if err != nil {
+		return err
+	}
+
+	var expressions []string
+	err = db.Raw(`
+		SELECT pg_get_expr(d.adbin, d.adrelid)
+		FROM pg_attrdef d
+		JOIN pg_attribute a ON a.attrelid = d.adrelid AND a.attnum = d.adnum
+		WHERE d.adrelid = 'todos'::regclass AND a.attname = 'search_vector'`).Scan(&expressions).Error
+	if err != nil {
+		return err
+	}
+
+	if len(expressions) > 0 && strings.Contains(expressions[0], "'"+lang+"'::regconfig") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`ALTER TABLE todos DROP COLUMN IF EXISTS search_vector`).Error; err != nil {
+			return err
+		}
+
+		err := tx.Exec(fmt.Sprintf(`
+			ALTER TABLE todos ADD COLUMN search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('%s'::regconfig, todo_search_text(title, todo))) STORED`, lang)).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`CREATE INDEX idx_todos_search_vector ON todos USING GIN (search_vector)`).Error
+	})
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"practice/env"
+	"practice/pkg/logger"
+	"practice/pkg/storage"
+)
+
+// NewStorage returns the blob store selected by STORAGE_DRIVER.
+func NewStorage(logger *logger.Logger) storage.Blob {
+	switch env.StorageDriver {
+	case "local":
+		if env.StorageSigningKey == "" {
+			// an empty key would let anyone forge signed URLs
+			logger.Fatal("STORAGE_SIGNING_KEY or JWT_SECRET_KEY must be set to sign file URLs")
+		}
+		blob, err := storage.NewLocal(env.DirPath, env.StorageUrl, env.StorageSigningKey)
+		if err != nil {
+			logger.Fatal("failed to open storage directory", "dir", env.DirPath, "error", err)
+		}
+		logger.Info("storage: local", "dir", env.DirPath)
+		return blob
+	case "s3":
+		blob, err := storage.NewS3(storage.S3Config{
+			Endpoint:  env.StorageEndpoint,
+			Region:    env.StorageRegion,
+			Bucket:    env.GCSBucket,
+			AccessKey: env.StorageAccessKey,
+			SecretKey: env.StorageSecretKey,
+		})
+		if err != nil {
+			logger.Fatal("failed to configure storage", "error", err)
+		}
+		logger.Info("storage: s3", "endpoint", env.StorageEndpoint, "bucket", env.GCSBucket)
+		return blob
+	default:
+		logger.Fatal("unknown storage driver", "driver", env.StorageDriver)
+		return nil
+	}
+}
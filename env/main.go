@@ -22,8 +22,35 @@ var (
 	DirPath    string
 	StorageUrl string
 
+	StorageDriver     string
+	StorageEndpoint   string
+	StorageRegion     string
+	StorageAccessKey  string
+	StorageSecretKey  string
+	StorageSigningKey string
+	StorageQuota      uint64
+
+	UploadMaxFileSize    uint64
+	UploadMaxRequestSize uint64
+	UploadAllowedTypes   []string
+	MaxTodoImages        uint64
+	AttachmentTypes      []string
+	MaxTodoAttachments   uint64
+	ImageVariants        string
+	UploadOrphanTTL      uint64
+	ResumableMaxSize     uint64
+	ResumableExpiry      uint64
+	ResumableReadTimeout uint64
+	UploadSweepInterval  uint64
+
 	JWTSecretKey string
 
+	SearchLanguage string
+
+	StatsCacheTTL uint64
+
+	WebhookAllowedNetworks string
+
 	AppName string
 	Mode    string
 )
@@ -41,9 +68,38 @@ func GetEnv() {
 	DBPassword = os.Getenv("DB_PASSWORD")
 	DBName = os.Getenv("DB_NAME")
 
+	JWTSecretKey = os.Getenv("JWT_SECRET_KEY")
+
+	GCSBucket = os.Getenv("GCS_BUCKET")
 	DirPath = emptyDefault(os.Getenv("DIR_PATH"), "uploads")
+	StorageUrl = emptyDefault(os.Getenv("STORAGE_URL"), "/api/files")
 
-	JWTSecretKey = os.Getenv("JWT_SECRET_KEY")
+	StorageDriver = emptyDefault(os.Getenv("STORAGE_DRIVER"), "local")
+	StorageEndpoint = os.Getenv("STORAGE_ENDPOINT")
+	StorageRegion = os.Getenv("STORAGE_REGION")
+	StorageAccessKey = os.Getenv("STORAGE_ACCESS_KEY")
+	StorageSecretKey = os.Getenv("STORAGE_SECRET_KEY")
+	StorageSigningKey = emptyDefault(os.Getenv("STORAGE_SIGNING_KEY"), JWTSecretKey)
+	StorageQuota = parseToUint(os.Getenv("STORAGE_QUOTA"), 100<<20)
+
+	UploadMaxFileSize = parseToUint(os.Getenv("UPLOAD_MAX_FILE_SIZE"), 10<<20)
+	UploadMaxRequestSize = parseToUint(os.Getenv("UPLOAD_MAX_REQUEST_SIZE"), 50<<20)
+	UploadAllowedTypes = strings.Split(emptyDefault(os.Getenv("UPLOAD_ALLOWED_TYPES"), "image/jpeg,image/png,image/gif,image/webp"), ",")
+	MaxTodoImages = parseToUint(os.Getenv("MAX_TODO_IMAGES"), 10)
+	AttachmentTypes = strings.Split(emptyDefault(os.Getenv("ATTACHMENT_ALLOWED_TYPES"), "application/pdf,text/plain,text/csv"), ",")
+	MaxTodoAttachments = parseToUint(os.Getenv("MAX_TODO_ATTACHMENTS"), 20)
+	ImageVariants = emptyDefault(os.Getenv("IMAGE_VARIANTS"), "thumb:320x320,medium:1280x1280")
+	UploadOrphanTTL = parseToUint(os.Getenv("UPLOAD_ORPHAN_TTL"), 24*60*60)
+	ResumableMaxSize = parseToUint(os.Getenv("RESUMABLE_MAX_SIZE"), 1<<30)
+	ResumableExpiry = parseToUint(os.Getenv("RESUMABLE_EXPIRY"), 24*60*60)
+	ResumableReadTimeout = parseToUint(os.Getenv("RESUMABLE_READ_TIMEOUT"), 10*60)
+	UploadSweepInterval = parseToUint(os.Getenv("UPLOAD_SWEEP_INTERVAL"), 60*60)
+
+	SearchLanguage = emptyDefault(os.Getenv("SEARCH_LANGUAGE"), "english")
+
+	StatsCacheTTL = parseToUint(os.Getenv("STATS_CACHE_TTL"))
+
+	WebhookAllowedNetworks = os.Getenv("WEBHOOK_ALLOWED_NETWORKS")
 }
 
 func parseToUint(val string, def ...uint64) uint64 {
@@ -3,10 +3,15 @@ package main
 import (
 	"bytes"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"practice/pkg/middleware"
+	"practice/pkg/storage"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,36 +39,99 @@ func TestRouteHelloWorld(t *testing.T) {
 var contohFile []byte
 
 func TestFormUpload(t *testing.T) {
-	app.Post("/upload", func(c *fiber.Ctx) error {
-		file, err := c.FormFile("file")
-		if err != nil {
-			return err
-		}
-
-		err = c.SaveFile(file, "./uploads"+file.Filename)
-		if err != nil {
-			return err
-		}
-
-		return c.SendString("File uploaded successfully")
+	blob, err := storage.NewLocal(t.TempDir(), "/api/files", "secret")
+	assert.Nil(t, err)
+
+	app.Post("/upload", middleware.Upload(blob, middleware.UploadLimits{
+		MaxFileSize:  1 << 20,
+		MaxFiles:     2,
+		AllowedTypes: []string{"image/png"},
+	}), func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals("filenames"))
+	})
+
+	upload := func(name string, content []byte) *http.Response {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		file, err := writer.CreateFormFile("images", name)
+		assert.Nil(t, err)
+		file.Write(content)
+		writer.Close()
+
+		request := httptest.NewRequest("POST", "/upload", body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	// the type comes from the content, not from the name
+	response := upload("contoh.png", contohFile)
+	assert.Equal(t, 415, response.StatusCode)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	response = upload("contoh.txt", png)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var filenames []string
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&filenames))
+	assert.Len(t, filenames, 1)
+	assert.True(t, strings.HasSuffix(filenames[0], ".png"))
+
+	response = upload("big.png", append(png, make([]byte, 1<<20)...))
+	assert.Equal(t, 413, response.StatusCode)
+}
+
+func TestFormUploadFiles(t *testing.T) {
+	blob, err := storage.NewLocal(t.TempDir(), "/api/files", "secret")
+	assert.Nil(t, err)
+
+	app.Post("/attach", middleware.UploadFiles(blob, "files", middleware.UploadLimits{
+		AllowedTypes: []string{"text/plain"},
+	}), func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals("uploads"))
 	})
 
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
-	file, err := writer.CreateFormFile("file", "contoh.txt")
+	file, err := writer.CreateFormFile("files", "../../notes.txt")
 	assert.Nil(t, err)
-	file.Write(contohFile)
+	file.Write([]byte("hello world"))
 	writer.Close()
 
-	request := httptest.NewRequest("POST", "/upload", body)
+	request := httptest.NewRequest("POST", "/attach", body)
 	request.Header.Set("Content-Type", writer.FormDataContentType())
 	response, err := app.Test(request)
 	assert.Nil(t, err)
 	assert.Equal(t, 200, response.StatusCode)
 
-	bytes, err := io.ReadAll(response.Body)
+	var uploads []middleware.UploadedFile
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&uploads))
+	assert.Len(t, uploads, 1)
+	assert.Equal(t, "notes.txt", uploads[0].Filename)
+	assert.Equal(t, int64(11), uploads[0].Size)
+	assert.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", uploads[0].Checksum)
+	assert.True(t, strings.HasPrefix(uploads[0].ContentType, "text/plain"))
+	assert.Equal(t, uploads[0].Checksum+".txt", uploads[0].Key)
+
+	// the same content again is stored under the same key
+	body = new(bytes.Buffer)
+	writer = multipart.NewWriter(body)
+	file, err = writer.CreateFormFile("files", "copy.txt")
+	assert.Nil(t, err)
+	file.Write([]byte("hello world"))
+	writer.Close()
+
+	request = httptest.NewRequest("POST", "/attach", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	response, err = app.Test(request)
 	assert.Nil(t, err)
-	assert.Equal(t, "File uploaded successfully", string(bytes))
+
+	var copies []middleware.UploadedFile
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&copies))
+	assert.Len(t, copies, 1)
+	assert.Equal(t, uploads[0].Key, copies[0].Key)
+	assert.Equal(t, "copy.txt", copies[0].Filename)
 }
 
 type User struct {
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/bus"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FileHandler interface {
+	ImageUploaded(ctx context.Context, event models.ImageUploaded, metadata bus.Metadata) error
+
+	// VerifySignature serves files requested through a signed URL and
+	// passes other requests on.
+	VerifySignature(c *fiber.Ctx) error
+	GetFile(c *fiber.Ctx) error
+	GetUsage(c *fiber.Ctx) error
+	// HeadChecksum tells whether the user has uploaded the file with the
+	// checksum in the path, and under which key.
+	HeadChecksum(c *fiber.Ctx) error
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"practice/internal/file/usecase"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/storage"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FileHandlerImpl struct {
+	usecase usecase.FileUsecase
+	logger  *logger.Logger
+}
+
+func NewFileHandler(usecase usecase.FileUsecase, logger *logger.Logger) FileHandler {
+	return &FileHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+// ImageUploaded processes the image in the background. A failure is
+// returned so the bus retries it.
+func (h *FileHandlerImpl) ImageUploaded(ctx context.Context, event models.ImageUploaded, metadata bus.Metadata) error {
+	return h.usecase.ProcessImage(ctx, event.UserID, event.Key)
+}
+
+// VerifySignature lets browsers load files in img tags, which cannot send
+// the Authorization header. The response may be cached until the URL
+// expires.
+func (h *FileHandlerImpl) VerifySignature(c *fiber.Ctx) error {
+	signature := c.Query("signature")
+	if signature == "" {
+		return c.Next()
+	}
+
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return fail(c, h.logger, usecase.ErrNotFound)
+	}
+
+	expiresAt, err := h.usecase.Verify(key, c.Query("expires"), signature)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	maxAge := int(time.Until(expiresAt) / time.Second)
+	return h.serve(c, key, fmt.Sprintf("private, max-age=%d", maxAge))
+}
+
+func (h *FileHandlerImpl) GetFile(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return fail(c, h.logger, usecase.ErrNotFound)
+	}
+
+	if err := h.usecase.Authorize(c.Context(), userID, key); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return h.serve(c, key, "private, no-cache")
+}
+
+func (h *FileHandlerImpl) GetUsage(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	usage, err := h.usecase.GetUsage(c.Context(), userID)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    usage,
+	})
+}
+
+// HeadChecksum answers with the headers of the file the user has uploaded
+// and its URL in Content-Location, or 404. Answering HEAD only, it sends no
+// body.
+func (h *FileHandlerImpl) HeadChecksum(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.SendStatus(fiber.StatusBadRequest)
+	}
+
+	checksum := c.Params("checksum")
+	upload, err := h.usecase.GetByChecksum(c.Context(), userID, checksum)
+	if errors.Is(err, usecase.ErrNotFound) {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	if err != nil {
+		h.logger.Error(err.Error())
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	c.Set(fiber.HeaderContentLocation, strings.TrimSuffix(c.Path(), "sha256/"+checksum)+upload.Key)
+	c.Set(fiber.HeaderContentType, upload.ContentType)
+	c.Response().Header.SetContentLength(int(upload.Size))
+	c.Status(fiber.StatusOK)
+	return nil
+}
+
+// serve sends the file, or the single byte range asked for, with the
+// validators needed for conditional and resumed downloads.
+func (h *FileHandlerImpl) serve(c *fiber.Ctx, key string, cacheControl string) error {
+	info, err := h.usecase.Stat(c.Context(), key)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, cacheControl)
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	// uploads are user content; never let browsers run them as a page
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; sandbox")
+	if info.ETag != "" {
+		c.Set(fiber.HeaderETag, info.ETag)
+	}
+	if !info.ModTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, info.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	if info.ETag != "" && c.Get(fiber.HeaderIfNoneMatch) == info.ETag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	rangeHeader := c.Get(fiber.HeaderRange)
+	if ifRange := c.Get(fiber.HeaderIfRange); ifRange != "" && ifRange != info.ETag {
+		rangeHeader = ""
+	}
+
+	offset, length, partial, err := storage.ParseRange(rangeHeader, info.Size)
+	if errors.Is(err, storage.ErrUnsatisfiableRange) {
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", info.Size))
+		return c.Status(fiber.StatusRequestedRangeNotSatisfiable).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	r, err := h.usecase.Open(c.Context(), key, offset, length)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	status := fiber.StatusOK
+	if partial {
+		status = fiber.StatusPartialContent
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, info.Size))
+	}
+
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	return c.Status(status).SendStream(r, int(length))
+}
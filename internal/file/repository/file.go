@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FileRepo interface {
+	// FileRepo is the ledger of storage.Metered, recording the uploads of
+	// each user.
+	storage.Ledger
+
+	// IsOwner reports whether a todo, template or attachment of the user
+	// references the uploaded file.
+	IsOwner(ctx context.Context, userID uuid.UUID, key string) (bool, error)
+	GetUsage(ctx context.Context, userID uuid.UUID) (*models.StorageUsage, error)
+	// GetUpload returns an upload of the file, a processed one if any.
+	GetUpload(ctx context.Context, key string) (*models.Upload, error)
+	// GetUploadByChecksum returns the user's upload of the file with the
+	// given hex SHA-256.
+	GetUploadByChecksum(ctx context.Context, userID uuid.UUID, checksum string) (*models.Upload, error)
+	// SetVariants marks the upload as processed.
+	SetVariants(ctx context.Context, key string, variants []string) error
+	// GetUnprocessed returns an upload of up to limit images of todos or
+	// templates, of the given types, that were stored before the given time
+	// and are not processed yet.
+	GetUnprocessed(ctx context.Context, types []string, before time.Time, limit int) ([]*models.Upload, error)
+
+	// GetOrphans returns an upload of each file no todo, template,
+	// attachment or resumable upload references, once every upload of the
+	// file was attached or stored before the given time. Variants count as
+	// referenced while the upload they belong to exists.
+	GetOrphans(ctx context.Context, before time.Time) ([]*models.Upload, error)
+	// GetUploadKeys returns the keys of the uploads stored before the given
+	// time.
+	GetUploadKeys(ctx context.Context, before time.Time) ([]string, error)
+	// GetFileOwners returns the owners of the todos, templates, attachments,
+	// uploads or resumable uploads that reference the given keys.
+	// Unreferenced keys are left out.
+	GetFileOwners(ctx context.Context, keys []string) (map[string]uuid.UUID, error)
+	// AdoptUpload records a stored file that has no upload yet.
+	AdoptUpload(ctx context.Context, upload *models.Upload) error
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FileRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewFileRepo(db *gorm.DB) FileRepo {
+	return &FileRepoImpl{
+		db: db,
+	}
+}
+
+func (r *FileRepoImpl) IsOwner(ctx context.Context, userID uuid.UUID, key string) (bool, error) {
+	var owned bool
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (SELECT 1 FROM todos WHERE user_id = ? AND ? = ANY(images))
+			OR EXISTS (SELECT 1 FROM todo_templates WHERE user_id = ? AND ? = ANY(images))
+			OR EXISTS (SELECT 1 FROM attachments WHERE user_id = ? AND key = ?)`,
+		userID, key, userID, key, userID, key,
+	).Scan(&owned).Error
+	return owned, err
+}
+
+func (r *FileRepoImpl) GetUsage(ctx context.Context, userID uuid.UUID) (*models.StorageUsage, error) {
+	usage := new(models.StorageUsage)
+	err := r.db.WithContext(ctx).Model(&models.Upload{}).
+		Select("COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").
+		Where("user_id = ?", userID).
+		Scan(usage).Error
+	return usage, err
+}
+
+func (r *FileRepoImpl) GetUpload(ctx context.Context, key string) (*models.Upload, error) {
+	var upload *models.Upload
+	err := r.db.WithContext(ctx).Model(&models.Upload{}).
+		Where("key = ?", key).
+		Order("processed_at IS NULL, created_at").
+		First(&upload).Error
+	return upload, err
+}
+
+func (r *FileRepoImpl) GetUploadByChecksum(ctx context.Context, userID uuid.UUID, checksum string) (*models.Upload, error) {
+	var upload *models.Upload
+	err := r.db.WithContext(ctx).Model(&models.Upload{}).
+		Where("user_id = ? AND (key = ? OR key LIKE ?)", userID, checksum, checksum+".%").
+		First(&upload).Error
+	return upload, err
+}
+
+func (r *FileRepoImpl) SetVariants(ctx context.Context, key string, variants []string) error {
+	return r.db.WithContext(ctx).Model(&models.Upload{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{
+			"variants":     pq.StringArray(variants),
+			"processed_at": time.Now(),
+		}).Error
+}
+
+func (r *FileRepoImpl) GetUnprocessed(ctx context.Context, types []string, before time.Time, limit int) ([]*models.Upload, error) {
+	var uploads []*models.Upload
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (u.key) u.* FROM uploads u
+		WHERE u.processed_at IS NULL AND u.created_at < ? AND u.content_type IN ?
+			AND NOT EXISTS (SELECT 1 FROM uploads p WHERE p.key = u.key AND p.processed_at IS NOT NULL)
+			AND (EXISTS (SELECT 1 FROM todos WHERE u.key = ANY(images))
+				OR EXISTS (SELECT 1 FROM todo_templates WHERE u.key = ANY(images)))
+		ORDER BY u.key, u.created_at
+		LIMIT ?`,
+		before, types, limit,
+	).Scan(&uploads).Error
+	return uploads, err
+}
+
+func (r *FileRepoImpl) GetOrphans(ctx context.Context, before time.Time) ([]*models.Upload, error) {
+	var uploads []*models.Upload
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (u.key) u.* FROM uploads u
+		WHERE NOT EXISTS (SELECT 1 FROM uploads h WHERE h.key = u.key AND h.attached_at IS NULL AND h.created_at >= ?)
+			AND NOT EXISTS (SELECT 1 FROM todos WHERE u.key = ANY(images))
+			AND NOT EXISTS (SELECT 1 FROM todo_templates WHERE u.key = ANY(images))
+			AND NOT EXISTS (SELECT 1 FROM attachments WHERE attachments.key = u.key)
+			AND NOT EXISTS (SELECT 1 FROM resumable_uploads r WHERE r.key = u.key)
+			AND NOT EXISTS (SELECT 1 FROM uploads p WHERE u.key = ANY(p.variants))
+		ORDER BY u.key, u.processed_at IS NULL`,
+		before,
+	).Scan(&uploads).Error
+	return uploads, err
+}
+
+func (r *FileRepoImpl) GetUploadKeys(ctx context.Context, before time.Time) ([]string, error) {
+	var keys []string
+	err := r.db.WithContext(ctx).Model(&models.Upload{}).
+		Where("created_at < ?", before).
+		Pluck("key", &keys).Error
+	return keys, err
+}
+
+func (r *FileRepoImpl) GetFileOwners(ctx context.Context, keys []string) (map[string]uuid.UUID, error) {
+	owners := make(map[string]uuid.UUID)
+	if len(keys) == 0 {
+		return owners, nil
+	}
+
+	var rows []struct {
+		Key    string
+		UserID uuid.UUID
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT k.key, o.user_id FROM unnest(?::text[]) AS k(key)
+		JOIN LATERAL (
+			SELECT user_id FROM todos WHERE k.key = ANY(images)
+			UNION ALL
+			SELECT user_id FROM todo_templates WHERE k.key = ANY(images)
+			UNION ALL
+			SELECT user_id FROM attachments WHERE attachments.key = k.key
+			UNION ALL
+			SELECT user_id FROM uploads WHERE k.key = ANY(variants)
+			UNION ALL
+			SELECT user_id FROM resumable_uploads r WHERE r.key = k.key
+			LIMIT 1
+		) o ON true`,
+		pq.StringArray(keys),
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		owners[row.Key] = row.UserID
+	}
+	return owners, nil
+}
+
+func (r *FileRepoImpl) AdoptUpload(ctx context.Context, upload *models.Upload) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(upload).Error
+}
+
+func (r *FileRepoImpl) UploadedBytes(ctx context.Context, owner string) (int64, error) {
+	userID, err := uuid.Parse(owner)
+	if err != nil {
+		return 0, err
+	}
+
+	var bytes int64
+	err = r.db.WithContext(ctx).Model(&models.Upload{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("user_id = ?", userID).
+		Scan(&bytes).Error
+	return bytes, err
+}
+
+func (r *FileRepoImpl) HasUpload(ctx context.Context, owner string, key string) (bool, error) {
+	userID, err := uuid.Parse(owner)
+	if err != nil {
+		return false, err
+	}
+
+	var held bool
+	err = r.db.WithContext(ctx).Raw(
+		"SELECT EXISTS (SELECT 1 FROM uploads WHERE user_id = ? AND key = ?)",
+		userID, key,
+	).Scan(&held).Error
+	return held, err
+}
+
+func (r *FileRepoImpl) AddUpload(ctx context.Context, owner string, info storage.Info) error {
+	userID, err := uuid.Parse(owner)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "content_type", "updated_at"}),
+	}).Create(&models.Upload{
+		Key:         info.Key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		UserID:      userID,
+	}).Error
+}
+
+func (r *FileRepoImpl) RemoveUpload(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&models.Upload{}).Error
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"context"
+	"practice/config"
+	"practice/env"
+	"practice/internal/file/handler"
+	"practice/internal/file/repository"
+	"practice/internal/file/usecase"
+	"practice/pkg/bus"
+	"practice/pkg/imaging"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route mounts the download endpoint of uploaded files. A file is served
+// either through a signed URL or to an authenticated user who owns a todo
+// or template referencing it; users look up their own uploads by checksum.
+// Uploaded images are processed as their image.uploaded events arrive, and
+// the sweeper processes those whose event got lost. Orphaned uploads are
+// swept until ctx is cancelled.
+func Route(ctx context.Context, f fiber.Router, db *config.DB, logger *logger.Logger, event *bus.EventBus, blob storage.Blob) {
+	variants, err := imaging.ParseVariants(env.ImageVariants)
+	if err != nil {
+		logger.Fatal("invalid IMAGE_VARIANTS", "error", err)
+	}
+
+	repo := repository.NewFileRepo(db.Instance())
+	usecase := usecase.NewFileUsecase(repo, blob, int64(env.StorageQuota), variants, logger)
+	handler := handler.NewFileHandler(usecase, logger)
+
+	event.Register(
+		bus.On(handler.ImageUploaded),
+	)
+
+	go usecase.Run(ctx)
+
+	f.Get("/files/usage", middleware.JWTAuth(), handler.GetUsage)
+	f.Head("/files/sha256/:checksum", middleware.JWTAuth(), handler.HeadChecksum)
+	f.Get("/files/*", handler.VerifySignature, middleware.JWTAuth(), handler.GetFile)
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"practice/models"
+	"practice/pkg/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FileUsecase interface {
+	// Authorize checks that the user may download the file.
+	Authorize(ctx context.Context, userID uuid.UUID, key string) error
+	// Verify checks a signed URL of the file and returns when it expires.
+	Verify(key string, expires string, signature string) (time.Time, error)
+	Stat(ctx context.Context, key string) (*storage.Info, error)
+	// Open reads length bytes of the file starting at offset.
+	Open(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error)
+	GetUsage(ctx context.Context, userID uuid.UUID) (*models.StorageUsage, error)
+	// GetByChecksum returns the user's upload of the file with the given
+	// hex SHA-256, so clients can skip uploading it again.
+	GetByChecksum(ctx context.Context, userID uuid.UUID, checksum string) (*models.Upload, error)
+
+	// ProcessImage strips the metadata of an uploaded image, turns it
+	// upright and stores its variants. Other files are left alone.
+	ProcessImage(ctx context.Context, userID uuid.UUID, key string) error
+
+	// Reconcile brings the blob store and the upload records in line. A
+	// dry run only reports what it would change.
+	Reconcile(ctx context.Context, dryRun bool) (*models.Reconciliation, error)
+	// Run processes images missed by ProcessImage and sweeps orphaned
+	// uploads periodically until ctx is cancelled.
+	Run(ctx context.Context)
+}
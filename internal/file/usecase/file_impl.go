@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"path"
+	"practice/internal/file/repository"
+	"practice/models"
+	"practice/pkg/imaging"
+	"practice/pkg/logger"
+	"practice/pkg/storage"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound  = errors.New("file not found")
+	ErrForbidden = errors.New("Forbidden")
+)
+
+// processedTypes are the image types ProcessImage decodes.
+var processedTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+type FileUsecaseImpl struct {
+	repo     repository.FileRepo
+	blob     storage.Blob
+	quota    int64
+	variants []imaging.Variant
+	logger   *logger.Logger
+}
+
+// NewFileUsecase returns the usecase of files in blob. quota is the storage
+// quota of each user in bytes, 0 is unlimited; images are rendered in each
+// of variants.
+func NewFileUsecase(repo repository.FileRepo, blob storage.Blob, quota int64, variants []imaging.Variant, logger *logger.Logger) FileUsecase {
+	return &FileUsecaseImpl{
+		repo:     repo,
+		blob:     blob,
+		quota:    quota,
+		variants: variants,
+		logger:   logger,
+	}
+}
+
+// Authorize reports files of other users as missing rather than forbidden,
+// so their names cannot be probed.
+func (u *FileUsecaseImpl) Authorize(ctx context.Context, userID uuid.UUID, key string) error {
+	key, err := storage.CleanKey(key)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	owned, err := u.repo.IsOwner(ctx, userID, key)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+	if !owned {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Verify only accepts URLs of drivers serving their files through the
+// application; other drivers sign URLs of the storage service itself.
+func (u *FileUsecaseImpl) Verify(key string, expires string, signature string) (time.Time, error) {
+	verifier, ok := u.blob.(storage.Verifier)
+	if !ok {
+		return time.Time{}, ErrForbidden
+	}
+
+	expiresAt, err := verifier.Verify(key, expires, signature, time.Now())
+	switch {
+	case errors.Is(err, storage.ErrInvalidKey):
+		return time.Time{}, ErrNotFound
+	case err != nil:
+		u.logger.Debug(err.Error())
+		return time.Time{}, ErrForbidden
+	}
+
+	return expiresAt, nil
+}
+
+func (u *FileUsecaseImpl) Stat(ctx context.Context, key string) (*storage.Info, error) {
+	info, err := u.blob.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return nil, ErrNotFound
+	}
+
+	return info, err
+}
+
+func (u *FileUsecaseImpl) Open(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	var r io.ReadCloser
+	var err error
+	if ranged, ok := u.blob.(storage.RangeReader); ok {
+		r, err = ranged.GetRange(ctx, key, offset, length)
+	} else {
+		r, err = u.blob.Get(ctx, key)
+		if err == nil {
+			r, err = skip(r, offset, length)
+		}
+	}
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return nil, ErrNotFound
+	}
+
+	return r, err
+}
+
+func (u *FileUsecaseImpl) GetUsage(ctx context.Context, userID uuid.UUID) (*models.StorageUsage, error) {
+	usage, err := u.repo.GetUsage(ctx, userID)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	usage.Quota = u.quota
+	if u.quota > 0 {
+		usage.Remaining = max(u.quota-usage.Bytes, 0)
+	}
+
+	return usage, nil
+}
+
+// GetByChecksum reports files other users hold as missing; telling them
+// apart would reveal whether anyone has stored a given file.
+func (u *FileUsecaseImpl) GetByChecksum(ctx context.Context, userID uuid.UUID, checksum string) (*models.Upload, error) {
+	if !isChecksum(checksum) {
+		return nil, ErrNotFound
+	}
+
+	upload, err := u.repo.GetUploadByChecksum(ctx, userID, checksum)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// ProcessImage replaces the original with the processed image and stores
+// the variants under variants/<name of the original>/. The variants count
+// against the user's quota. Processing is done once per upload, so the
+// event may be delivered again; images whose event got lost are processed
+// by the sweeper.
+func (u *FileUsecaseImpl) ProcessImage(ctx context.Context, userID uuid.UUID, key string) error {
+	upload, err := u.repo.GetUpload(ctx, key)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if upload != nil && upload.ProcessedAt != nil {
+		return nil
+	}
+
+	info, err := u.blob.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			// deleted before it was processed
+			return nil
+		}
+		return err
+	}
+	if !isProcessed(info.ContentType) {
+		return nil
+	}
+
+	data, err := u.read(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	original, renditions, err := imaging.Process(data, u.variants)
+	if err != nil {
+		// retrying will not help; the original is still served
+		u.logger.Warn("failed to process image", "key", key, "error", err)
+		return u.repo.SetVariants(ctx, key, nil)
+	}
+
+	ctx = storage.WithOwner(ctx, userID.String())
+
+	// the metadata goes first, even if there is no quota for the variants
+	if !bytes.Equal(original.Data, data) {
+		if err := u.put(ctx, key, *original); err != nil {
+			return err
+		}
+	}
+
+	var variants []string
+	stem := strings.TrimSuffix(path.Base(key), path.Ext(key))
+	for _, rendition := range renditions {
+		variant := path.Join("variants", stem, rendition.Name+imaging.Extension(rendition.Format))
+		if err := u.put(ctx, variant, rendition); err != nil {
+			u.removeVariants(ctx, variants)
+			if errors.Is(err, storage.ErrQuotaExceeded) {
+				u.logger.Warn("no quota left for image variants", "key", key, "user", userID)
+				return u.repo.SetVariants(ctx, key, nil)
+			}
+			return err
+		}
+		variants = append(variants, variant)
+	}
+
+	return u.repo.SetVariants(ctx, key, variants)
+}
+
+func (u *FileUsecaseImpl) read(ctx context.Context, key string) ([]byte, error) {
+	r, err := u.blob.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func (u *FileUsecaseImpl) put(ctx context.Context, key string, rendition imaging.Rendition) error {
+	return u.blob.Put(ctx, key, bytes.NewReader(rendition.Data), storage.PutOptions{
+		ContentType: imaging.ContentType(rendition.Format),
+	})
+}
+
+func (u *FileUsecaseImpl) removeVariants(ctx context.Context, variants []string) {
+	for _, variant := range variants {
+		u.blob.Delete(ctx, variant)
+	}
+}
+
+// isChecksum reports whether s is a lowercase hex SHA-256.
+func isChecksum(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isProcessed(contentType string) bool {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	for _, t := range processedTypes {
+		if strings.EqualFold(strings.TrimSpace(contentType), t) {
+			return true
+		}
+	}
+	return false
+}
+
+// skip reads past offset bytes of r and limits it to length bytes, for
+// drivers that cannot read ranges themselves.
+func skip(r io.ReadCloser, offset int64, length int64) (io.ReadCloser, error) {
+	if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(r, length), r}, nil
+}
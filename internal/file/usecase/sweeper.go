@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"path"
+	"practice/env"
+	"practice/models"
+	"practice/pkg/storage"
+	"time"
+)
+
+// Reconcile deletes orphaned uploads: files of failed requests that were
+// never attached to a todo, template or attachment within
+// UPLOAD_ORPHAN_TTL, and files that lost their last reference. When the
+// store can list its objects, files without an upload record are deleted as
+// well, or adopted when something references them, and records whose file
+// is gone are removed.
+func (u *FileUsecaseImpl) Reconcile(ctx context.Context, dryRun bool) (*models.Reconciliation, error) {
+	now := time.Now()
+	cutoff := now.Add(-time.Duration(env.UploadOrphanTTL) * time.Second)
+	report := &models.Reconciliation{
+		Orphans:   []string{},
+		Untracked: []string{},
+		Adopted:   []string{},
+		Missing:   []string{},
+	}
+
+	orphans, err := u.repo.GetOrphans(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for _, orphan := range orphans {
+		if !dryRun {
+			// variants first, they are only found through the original
+			for _, variant := range orphan.Variants {
+				if err := u.blob.Delete(ctx, variant); err != nil {
+					return nil, err
+				}
+			}
+			if err := u.blob.Delete(ctx, orphan.Key); err != nil {
+				return nil, err
+			}
+		}
+		report.Orphans = append(report.Orphans, orphan.Key)
+		report.Bytes += orphan.Size
+	}
+
+	lister, ok := u.blob.(storage.Lister)
+	if !ok {
+		return report, nil
+	}
+
+	keys, err := u.repo.GetUploadKeys(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		tracked[key] = true
+	}
+
+	var untracked []storage.Info
+	seen := make(map[string]bool, len(keys))
+	err = lister.List(ctx, "", func(info storage.Info) error {
+		seen[info.Key] = true
+		if !tracked[info.Key] {
+			untracked = append(untracked, info)
+		}
+		return nil
+	})
+	if errors.Is(err, errors.ErrUnsupported) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	untrackedKeys := make([]string, len(untracked))
+	for i, info := range untracked {
+		untrackedKeys[i] = info.Key
+	}
+	owners, err := u.repo.GetFileOwners(ctx, untrackedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range untracked {
+		if owner, ok := owners[info.Key]; ok {
+			if !dryRun {
+				err := u.repo.AdoptUpload(ctx, &models.Upload{
+					Key:         info.Key,
+					Size:        info.Size,
+					ContentType: contentType(info),
+					UserID:      owner,
+					AttachedAt:  &now,
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			report.Adopted = append(report.Adopted, info.Key)
+			continue
+		}
+
+		// may be an upload whose record is not written yet
+		if !info.ModTime.Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := u.blob.Delete(ctx, info.Key); err != nil {
+				return nil, err
+			}
+		}
+		report.Untracked = append(report.Untracked, info.Key)
+		report.Bytes += info.Size
+	}
+
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		if !dryRun {
+			if err := u.repo.RemoveUpload(ctx, key); err != nil {
+				return nil, err
+			}
+		}
+		report.Missing = append(report.Missing, key)
+	}
+
+	return report, nil
+}
+
+const (
+	// processDelay leaves new images to their image.uploaded event.
+	processDelay     = 10 * time.Minute
+	processBatchSize = 100
+)
+
+// processPending processes the images whose image.uploaded event was lost,
+// e.g. to a restart, so none is served with its metadata for long.
+func (u *FileUsecaseImpl) processPending(ctx context.Context) (int, error) {
+	uploads, err := u.repo.GetUnprocessed(ctx, processedTypes, time.Now().Add(-processDelay), processBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, upload := range uploads {
+		if err := u.ProcessImage(ctx, upload.UserID, upload.Key); err != nil {
+			if ctx.Err() != nil {
+				return processed, ctx.Err()
+			}
+			u.logger.Warn("failed to process pending image", "key", upload.Key, "error", err)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// Run processes pending images and reconciles the store every
+// UPLOAD_SWEEP_INTERVAL until ctx is cancelled.
+func (u *FileUsecaseImpl) Run(ctx context.Context) {
+	if env.UploadSweepInterval == 0 {
+		return
+	}
+
+	sweep := time.NewTicker(time.Duration(env.UploadSweepInterval) * time.Second)
+	defer sweep.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sweep.C:
+			processed, err := u.processPending(ctx)
+			if err != nil && ctx.Err() == nil {
+				u.logger.Error("processing pending images failed", "error", err)
+			}
+			if processed > 0 {
+				u.logger.Info("pending images processed", "images", processed)
+			}
+
+			report, err := u.Reconcile(ctx, false)
+			if err != nil {
+				if ctx.Err() == nil {
+					u.logger.Error("upload sweep failed", "error", err)
+				}
+				continue
+			}
+			if len(report.Orphans)+len(report.Untracked)+len(report.Adopted)+len(report.Missing) > 0 {
+				u.logger.Info("upload sweep done",
+					"orphans", len(report.Orphans),
+					"untracked", len(report.Untracked),
+					"adopted", len(report.Adopted),
+					"missing", len(report.Missing),
+					"bytes", report.Bytes,
+				)
+			}
+		}
+	}
+}
+
+// contentType returns the content type of a listed object, which some
+// stores only report on Stat.
+func contentType(info storage.Info) string {
+	if info.ContentType != "" {
+		return info.ContentType
+	}
+	if t := mime.TypeByExtension(path.Ext(info.Key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
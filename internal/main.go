@@ -1,17 +1,37 @@
 package internal
 
 import (
+	"context"
 	"practice/config"
+	"practice/env"
+	fileRepository "practice/internal/file/repository"
+	fileRouter "practice/internal/file/router"
+	realtimeRouter "practice/internal/realtime/router"
+	resumableRouter "practice/internal/resumable/router"
 	todoRouter "practice/internal/todo/router"
 	userRouter "practice/internal/user/router"
+	webhookRouter "practice/internal/webhook/router"
+	"practice/pkg/bus"
 	"practice/pkg/logger"
+	"practice/pkg/outbox"
+	"practice/pkg/storage"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func MainRoutes(f *fiber.App, db *config.DB, logger *logger.Logger) {
+// MainRoutes mounts every module on the app. Modules share the event bus,
+// so each sees the others' events, the outbox relay feeding it and the blob
+// store for uploads. Their background workers run until ctx is cancelled.
+func MainRoutes(ctx context.Context, f *fiber.App, db *config.DB, logger *logger.Logger, event *bus.EventBus, relay *outbox.Relay, blob storage.Blob) {
 	api := f.Group("/api")
 
-	userRouter.Route(api, db, logger)
-	todoRouter.Route(api, db, logger)
+	// uploads count against the quota of the user storing them
+	blob = storage.NewMetered(blob, fileRepository.NewFileRepo(db.Instance()), int64(env.StorageQuota))
+
+	userRouter.Route(api, db, logger, event)
+	todoRouter.Route(api, db, logger, event, relay, blob)
+	webhookRouter.Route(ctx, api, db, logger, event)
+	realtimeRouter.Route(ctx, api, logger, event)
+	fileRouter.Route(ctx, api, db, logger, event, blob)
+	resumableRouter.Route(ctx, api, db, logger, blob)
 }
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"practice/pkg/bus"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RealtimeHandler interface {
+	bus.ContextHandler
+
+	Upgrade(c *fiber.Ctx) error
+	WebSocket(conn *websocket.Conn)
+	Events(c *fiber.Ctx) error
+}
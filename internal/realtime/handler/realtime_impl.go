@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/stream"
+	"strings"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	writeWait = 10 * time.Second
+	// pongWait is how long a connection may stay silent, it must be longer
+	// than pingInterval.
+	pongWait     = 60 * time.Second
+	pingInterval = 25 * time.Second
+	// clients only send control frames and the occasional close
+	maxMessageSize = 512
+
+	keepAliveInterval = 15 * time.Second
+	// retryDelay is how long EventSource clients wait before reconnecting.
+	retryDelay = 3 * time.Second
+)
+
+// webSocketEvents are the events pushed over WebSockets, which are used to
+// keep todo lists current. Event streams carry all of a user's events.
+var webSocketEvents = map[string]bool{
+	"todo.created": true,
+	"todo.updated": true,
+	"todo.deleted": true,
+}
+
+type RealtimeHandlerImpl struct {
+	hub    *stream.Hub
+	logger *logger.Logger
+}
+
+func NewRealtimeHandler(hub *stream.Hub, logger *logger.Logger) RealtimeHandler {
+	return &RealtimeHandlerImpl{
+		hub:    hub,
+		logger: logger,
+	}
+}
+
+// HandleContext forwards the event to the connections of the user it
+// belongs to.
+func (h *RealtimeHandlerImpl) HandleContext(ctx context.Context, event bus.Event) error {
+	owned, ok := event.Payload.(models.Owned)
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	h.hub.Publish(owned.OwnerID().String(), event.Type, data)
+
+	return nil
+}
+
+// Upgrade only lets WebSocket requests of an authenticated user through.
+func (h *RealtimeHandlerImpl) Upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+	c.Locals("userID", userID)
+
+	return c.Next()
+}
+
+// WebSocket pushes the user's events to the connection. A client that
+// reconnects with the ID of the last event it received, in the
+// last_event_id query parameter, first gets the events it missed, or a
+// resync message when they are no longer known and it should reload. The
+// connection is pinged to detect dead peers and closed when the client
+// falls too far behind or its token expires.
+func (h *RealtimeHandlerImpl) WebSocket(conn *websocket.Conn) {
+	userID, _ := conn.Locals("userID").(uuid.UUID)
+	lastID := stream.ParseEventID(conn.Query("last_event_id"))
+
+	client, err := h.hub.Subscribe(userID.String(), lastID, func(eventType string) bool {
+		return webSocketEvents[eventType]
+	})
+	if err != nil {
+		closeConn(conn, websocket.CloseGoingAway, "server shutting down")
+		return
+	}
+	defer client.Close()
+
+	if lastID > 0 && !client.Resumed() {
+		if err := writeJSON(conn, fiber.Map{"type": "resync"}); err != nil {
+			return
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		conn.SetReadLimit(maxMessageSize)
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	expiry := time.NewTimer(tokenLifetime(conn.Locals("user")))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case message := <-client.Messages():
+			if err := writeJSON(conn, message); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		case <-client.Done():
+			if errors.Is(client.Err(), stream.ErrSlowClient) {
+				closeConn(conn, websocket.CloseTryAgainLater, "too far behind, reconnect with last_event_id")
+			} else {
+				closeConn(conn, websocket.CloseGoingAway, "server shutting down")
+			}
+			return
+		case <-expiry.C:
+			closeConn(conn, websocket.ClosePolicyViolation, "token expired")
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Events streams the user's events as Server-Sent Events. The types query
+// parameter limits them to a comma-separated list of event types or
+// patterns such as "todo.*". Like WebSocket clients, a reconnecting
+// EventSource resumes after the Last-Event-ID it sends, or gets a resync
+// event. Comments are sent in between to keep proxies from timing out. The
+// stream ends with an expired event when the token expires.
+func (h *RealtimeHandlerImpl) Events(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	lastID := stream.ParseEventID(lastEventID)
+
+	client, err := h.hub.Subscribe(userID.String(), lastID, typeFilter(c.Query("types")))
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"message": "server shutting down",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// keep reverse proxies such as nginx from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	// the server's write timeout would cut the stream off, so every write
+	// sets its own deadline
+	conn := c.Context().Conn()
+	lifetime := tokenLifetime(c.Locals("user"))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer client.Close()
+
+		write := func(format string, args ...interface{}) error {
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			fmt.Fprintf(w, format, args...)
+			return w.Flush()
+		}
+
+		if err := write("retry: %d\n\n", retryDelay.Milliseconds()); err != nil {
+			return
+		}
+		if lastID > 0 && !client.Resumed() {
+			if err := write("event: resync\ndata: {}\n\n"); err != nil {
+				return
+			}
+		}
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		expiry := time.NewTimer(lifetime)
+		defer expiry.Stop()
+
+		for {
+			select {
+			case message := <-client.Messages():
+				data, err := json.Marshal(message)
+				if err != nil {
+					h.logger.Error(err.Error())
+					continue
+				}
+				if err := write("id: %s\nevent: %s\ndata: %s\n\n", message.EventID(), message.Type, data); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if err := write(": keep-alive\n\n"); err != nil {
+					return
+				}
+			case <-client.Done():
+				// a slow client reconnects and resumes, others end here
+				return
+			case <-expiry.C:
+				write("event: expired\ndata: {}\n\n")
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// typeFilter accepts the event types matching any of the comma-separated
+// types or patterns, or every type when there are none.
+func typeFilter(types string) func(eventType string) bool {
+	var patterns []string
+	for _, pattern := range strings.Split(types, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	return func(eventType string) bool {
+		for _, pattern := range patterns {
+			if bus.Match(pattern, eventType) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func writeJSON(conn *websocket.Conn, v interface{}) error {
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteJSON(v)
+}
+
+func closeConn(conn *websocket.Conn, code int, text string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(writeWait))
+}
+
+// tokenLifetime returns how long the token of the user local, which the
+// connection was opened with, stays valid. Tokens without expiry are good
+// for a day.
+func tokenLifetime(user interface{}) time.Duration {
+	claims, _ := user.(jwt.MapClaims)
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return 24 * time.Hour
+	}
+
+	return time.Until(expiresAt.Time)
+}
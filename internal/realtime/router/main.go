@@ -0,0 +1,33 @@
+package router
+
+import (
+	"context"
+	"practice/internal/realtime/handler"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/stream"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route mounts the real-time endpoints. Open connections are closed when
+// ctx is cancelled.
+func Route(ctx context.Context, f fiber.Router, logger *logger.Logger, event *bus.EventBus) {
+	hub := stream.NewHub(stream.DefaultOptions())
+	handler := handler.NewRealtimeHandler(hub, logger)
+
+	event.Register(
+		bus.OnContext("todo.*", handler),
+		bus.OnContext("user.*", handler),
+	)
+
+	go func() {
+		<-ctx.Done()
+		hub.Close()
+	}()
+
+	f.Get("/ws", middleware.StreamAuth(), handler.Upgrade, websocket.New(handler.WebSocket))
+	f.Get("/events", middleware.StreamAuth(), handler.Events)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"practice/internal/resumable/usecase"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errorStatuses = []middleware.ErrorStatus{
+	{Err: usecase.ErrNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrExpired, Status: fiber.StatusGone},
+	{Err: usecase.ErrOffsetMismatch, Status: fiber.StatusConflict},
+	{Err: usecase.ErrTooLarge, Status: fiber.StatusRequestEntityTooLarge},
+	{Err: storage.ErrQuotaExceeded, Status: fiber.StatusRequestEntityTooLarge},
+	{Err: usecase.ErrUnsupportedType, Status: fiber.StatusUnsupportedMediaType},
+}
+
+// fail maps usecase errors to their HTTP responses.
+func fail(c *fiber.Ctx, logger *logger.Logger, err error) error {
+	return middleware.Fail(c, logger, err, errorStatuses)
+}
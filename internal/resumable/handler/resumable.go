@@ -0,0 +1,17 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+// ResumableHandler speaks the tus protocol; see package tus.
+type ResumableHandler interface {
+	// Resumable rejects requests for other versions of the protocol and
+	// marks every response with the version served.
+	Resumable(c *fiber.Ctx) error
+	Options(c *fiber.Ctx) error
+	Create(c *fiber.Ctx) error
+	// Status answers HEAD requests with the offset to resume from.
+	Status(c *fiber.Ctx) error
+	GetUpload(c *fiber.Ctx) error
+	Patch(c *fiber.Ctx) error
+	Terminate(c *fiber.Ctx) error
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"practice/env"
+	"practice/internal/resumable/usecase"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/tus"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ResumableHandlerImpl struct {
+	usecase usecase.ResumableUsecase
+	logger  *logger.Logger
+}
+
+func NewResumableHandler(usecase usecase.ResumableUsecase, logger *logger.Logger) ResumableHandler {
+	return &ResumableHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+func (h *ResumableHandlerImpl) Resumable(c *fiber.Ctx) error {
+	c.Set(tus.HeaderResumable, tus.Version)
+	if c.Method() == fiber.MethodOptions {
+		return c.Next()
+	}
+
+	if c.Get(tus.HeaderResumable) != tus.Version {
+		c.Set(tus.HeaderVersion, tus.Version)
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"message": "unsupported tus version",
+		})
+	}
+
+	return c.Next()
+}
+
+func (h *ResumableHandlerImpl) Options(c *fiber.Ctx) error {
+	c.Set(tus.HeaderVersion, tus.Version)
+	c.Set(tus.HeaderExtension, tus.Extensions)
+	c.Set(tus.HeaderMaxSize, strconv.FormatUint(env.ResumableMaxSize, 10))
+	c.Set(tus.HeaderMaxChunkSize, strconv.FormatUint(env.UploadMaxRequestSize, 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ResumableHandlerImpl) Create(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	// the creation-defer-length extension is not supported
+	length, err := tus.ParseSize(c.Get(tus.HeaderLength))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid Upload-Length",
+		})
+	}
+
+	metadata, err := tus.ParseMetadata(c.Get(tus.HeaderMetadata))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	upload, err := h.usecase.Create(c.Context(), userID, length, metadata["filename"])
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	c.Location(c.BaseURL() + c.Path() + "/" + upload.ID.String())
+	c.Set(tus.HeaderOffset, strconv.FormatInt(upload.Offset, 10))
+	c.Set(tus.HeaderExpires, tus.FormatExpires(upload.ExpiresAt))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    upload,
+	})
+}
+
+func (h *ResumableHandlerImpl) Status(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	upload, err := h.usecase.Get(c.Context(), userID, id)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(tus.HeaderOffset, strconv.FormatInt(upload.Offset, 10))
+	c.Set(tus.HeaderLength, strconv.FormatInt(upload.Length, 10))
+	c.Set(tus.HeaderExpires, tus.FormatExpires(upload.ExpiresAt))
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *ResumableHandlerImpl) GetUpload(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	upload, err := h.usecase.Get(c.Context(), userID, id)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    upload,
+	})
+}
+
+// Patch writes one chunk as it arrives, so a chunk that is cut off keeps
+// the data received and the client resumes from the offset reported by
+// HEAD. Chunks must declare their length, of at most UPLOAD_MAX_REQUEST_SIZE.
+func (h *ResumableHandlerImpl) Patch(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	if c.Get(fiber.HeaderContentType) != tus.ContentType {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"message": "Content-Type must be " + tus.ContentType,
+		})
+	}
+
+	offset, err := tus.ParseSize(c.Get(tus.HeaderOffset))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid Upload-Offset",
+		})
+	}
+
+	length := c.Request().Header.ContentLength()
+	if length < 0 {
+		return c.Status(fiber.StatusLengthRequired).JSON(fiber.Map{
+			"message": "Content-Length required",
+		})
+	}
+	if uint64(length) > env.UploadMaxRequestSize {
+		c.Set(tus.HeaderMaxChunkSize, strconv.FormatUint(env.UploadMaxRequestSize, 10))
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"message": "chunk too large",
+			"limit":   env.UploadMaxRequestSize,
+		})
+	}
+
+	body := c.Request().BodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	upload, err := h.usecase.Write(c.Context(), userID, id, offset, io.LimitReader(body, int64(length)))
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	c.Set(tus.HeaderOffset, strconv.FormatInt(upload.Offset, 10))
+	c.Set(tus.HeaderExpires, tus.FormatExpires(upload.ExpiresAt))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ResumableHandlerImpl) Terminate(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	if err := h.usecase.Terminate(c.Context(), userID, id); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ResumableRepo interface {
+	AddUpload(ctx context.Context, upload *models.ResumableUpload) error
+	GetUpload(ctx context.Context, uuid uuid.UUID) (*models.ResumableUpload, error)
+	UpdateUpload(ctx context.Context, upload *models.ResumableUpload) error
+	// DeleteUpload reports whether the upload still existed.
+	DeleteUpload(ctx context.Context, uuid uuid.UUID) (bool, error)
+	// GetExpired returns the uploads that expired before now.
+	GetExpired(ctx context.Context, now time.Time) ([]*models.ResumableUpload, error)
+	// IsReferenced reports whether a todo, template, attachment or
+	// resumable upload references the stored file.
+	IsReferenced(ctx context.Context, key string) (bool, error)
+	// ReservedBytes returns the bytes the user has stored plus those still
+	// to come for unfinished uploads, which the storage quota must cover.
+	ReservedBytes(ctx context.Context, userID uuid.UUID, now time.Time) (int64, error)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ResumableRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewResumableRepo(db *gorm.DB) ResumableRepo {
+	return &ResumableRepoImpl{
+		db: db,
+	}
+}
+
+func (r *ResumableRepoImpl) AddUpload(ctx context.Context, upload *models.ResumableUpload) error {
+	return r.db.WithContext(ctx).Model(&models.ResumableUpload{}).Create(upload).Error
+}
+
+func (r *ResumableRepoImpl) GetUpload(ctx context.Context, uuid uuid.UUID) (*models.ResumableUpload, error) {
+	var upload *models.ResumableUpload
+	err := r.db.WithContext(ctx).Model(&models.ResumableUpload{}).First(&upload, "id = ?", uuid).Error
+	return upload, err
+}
+
+func (r *ResumableRepoImpl) UpdateUpload(ctx context.Context, upload *models.ResumableUpload) error {
+	return r.db.WithContext(ctx).Model(&models.ResumableUpload{}).Save(upload).Error
+}
+
+func (r *ResumableRepoImpl) DeleteUpload(ctx context.Context, uuid uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", uuid).Delete(&models.ResumableUpload{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ResumableRepoImpl) GetExpired(ctx context.Context, now time.Time) ([]*models.ResumableUpload, error) {
+	var uploads []*models.ResumableUpload
+	err := r.db.WithContext(ctx).Model(&models.ResumableUpload{}).
+		Where("expires_at < ?", now).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+func (r *ResumableRepoImpl) IsReferenced(ctx context.Context, key string) (bool, error) {
+	var referenced bool
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (SELECT 1 FROM todos WHERE ? = ANY(images))
+			OR EXISTS (SELECT 1 FROM todo_templates WHERE ? = ANY(images))
+			OR EXISTS (SELECT 1 FROM attachments WHERE key = ?)
+			OR EXISTS (SELECT 1 FROM resumable_uploads WHERE key = ?)`,
+		key, key, key, key,
+	).Scan(&referenced).Error
+	return referenced, err
+}
+
+func (r *ResumableRepoImpl) ReservedBytes(ctx context.Context, userID uuid.UUID, now time.Time) (int64, error) {
+	var bytes int64
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT (SELECT COALESCE(SUM(size), 0) FROM uploads WHERE user_id = ?)
+			+ (SELECT COALESCE(SUM(upload_length), 0) FROM resumable_uploads
+				WHERE user_id = ? AND completed_at IS NULL AND expires_at >= ?)`,
+		userID, userID, now,
+	).Scan(&bytes).Error
+	return bytes, err
+}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"practice/config"
+	"practice/env"
+	"practice/internal/resumable/handler"
+	"practice/internal/resumable/repository"
+	"practice/internal/resumable/usecase"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/storage"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route mounts the tus endpoints for resumable uploads. Partial files are
+// kept in a hidden directory of DIR_PATH, which the blob store does not
+// list, and finished ones are moved to blob. Expired uploads are cleaned up
+// until ctx is cancelled.
+func Route(ctx context.Context, f fiber.Router, db *config.DB, logger *logger.Logger, blob storage.Blob) {
+	dir := filepath.Join(env.DirPath, ".tus")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger.Fatal("failed to create the resumable upload directory", "dir", dir, "error", err)
+	}
+
+	repo := repository.NewResumableRepo(db.Instance())
+	usecase := usecase.NewResumableUsecase(repo, blob, dir, logger)
+	handler := handler.NewResumableHandler(usecase, logger)
+
+	go usecase.Run(ctx)
+
+	// clients discover the server before authenticating
+	f.Options("/uploads", handler.Resumable, handler.Options)
+	f.Options("/uploads/:id", handler.Resumable, handler.Options)
+
+	// chunks get their own read timeout before anything else answers, see
+	// middleware.StreamBody
+	stream := middleware.StreamBody(time.Duration(env.ResumableReadTimeout) * time.Second)
+	f.Patch("/uploads/:id", stream, middleware.JWTAuth(), handler.Resumable, handler.Patch)
+
+	uploads := f.Group("/uploads", middleware.JWTAuth(), handler.Resumable)
+	uploads.Post("", handler.Create)
+	uploads.Head("/:id", handler.Status)
+	uploads.Get("/:id", handler.GetUpload)
+	uploads.Delete("/:id", handler.Terminate)
+}
+
+// Streams reports whether the request is a chunk of a resumable upload,
+// whose body Patch writes to disk as it arrives instead of it being read
+// into memory first.
+func Streams(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodPatch && strings.HasPrefix(c.Path(), "/api/uploads/")
+}
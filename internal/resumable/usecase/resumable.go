@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"practice/models"
+
+	"github.com/google/uuid"
+)
+
+type ResumableUsecase interface {
+	// Create starts an upload of length bytes. An empty upload is complete
+	// right away.
+	Create(ctx context.Context, userID uuid.UUID, length int64, filename string) (*models.ResumableUpload, error)
+	// Get returns the upload, moving it to the blob store first when all
+	// of it arrived but storing it failed.
+	Get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.ResumableUpload, error)
+	// Write appends data at offset, which must be the current offset of
+	// the upload. Data is written as it is read, and when reading fails
+	// what was read is kept. The last chunk moves the file to the blob
+	// store; should that fail, an empty chunk at the end or Get retries it.
+	Write(ctx context.Context, userID uuid.UUID, id uuid.UUID, offset int64, data io.Reader) (*models.ResumableUpload, error)
+	// Terminate deletes the upload and any data received.
+	Terminate(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+
+	// Cleanup deletes expired uploads, finished or not.
+	Cleanup(ctx context.Context) error
+	// Run cleans up expired uploads periodically until ctx is cancelled.
+	Run(ctx context.Context)
+}
@@ -0,0 +1,370 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"practice/env"
+	"practice/internal/resumable/repository"
+	"practice/models"
+	"practice/pkg/filecheck"
+	"practice/pkg/logger"
+	"practice/pkg/storage"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound        = errors.New("upload not found")
+	ErrExpired         = errors.New("upload expired")
+	ErrOffsetMismatch  = errors.New("upload offset mismatch")
+	ErrTooLarge        = errors.New("upload too large")
+	ErrUnsupportedType = errors.New("unsupported file type")
+)
+
+// cleanupInterval is how often Run deletes expired uploads.
+const cleanupInterval = time.Hour
+
+type ResumableUsecaseImpl struct {
+	repo   repository.ResumableRepo
+	blob   storage.Blob
+	dir    string
+	logger *logger.Logger
+
+	// locks serializes the chunks of each upload.
+	locks sync.Map
+}
+
+// NewResumableUsecase returns the usecase of uploads whose partial data is
+// kept in dir until they are complete and stored in blob.
+func NewResumableUsecase(repo repository.ResumableRepo, blob storage.Blob, dir string, logger *logger.Logger) ResumableUsecase {
+	return &ResumableUsecaseImpl{
+		repo:   repo,
+		blob:   blob,
+		dir:    dir,
+		logger: logger,
+	}
+}
+
+// Create reserves the whole length against the storage quota, so an upload
+// that is accepted cannot fail on the quota halfway through.
+func (u *ResumableUsecaseImpl) Create(ctx context.Context, userID uuid.UUID, length int64, filename string) (*models.ResumableUpload, error) {
+	if length > int64(env.ResumableMaxSize) {
+		return nil, ErrTooLarge
+	}
+
+	now := time.Now()
+	if env.StorageQuota > 0 {
+		reserved, err := u.repo.ReservedBytes(ctx, userID, now)
+		if err != nil {
+			u.logger.Debug(err.Error())
+			return nil, err
+		}
+		if reserved+length > int64(env.StorageQuota) {
+			return nil, storage.ErrQuotaExceeded
+		}
+	}
+
+	upload := &models.ResumableUpload{
+		Length:    length,
+		Filename:  filecheck.CleanFilename(filename),
+		ExpiresAt: now.Add(time.Duration(env.ResumableExpiry) * time.Second),
+		UserID:    userID,
+	}
+	if err := u.repo.AddUpload(ctx, upload); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	file, err := os.Create(u.path(upload.ID))
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		u.logger.Error("failed to create upload file", "id", upload.ID, "error", err)
+		u.repo.DeleteUpload(ctx, upload.ID)
+		return nil, err
+	}
+
+	if length == 0 {
+		if err := u.complete(ctx, upload); err != nil {
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// Get finishes uploads whose data is all there but that failed to be
+// stored, so an upload reported at its full length always has a key.
+func (u *ResumableUsecaseImpl) Get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.ResumableUpload, error) {
+	upload, err := u.get(ctx, userID, id)
+	if err != nil || upload.Offset < upload.Length || upload.CompletedAt != nil {
+		return upload, err
+	}
+
+	unlock := u.lock(id)
+	defer unlock()
+
+	// another request may have finished it meanwhile
+	upload, err = u.get(ctx, userID, id)
+	if err != nil || upload.CompletedAt != nil {
+		return upload, err
+	}
+	if err := u.complete(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// get reports uploads of other users as missing.
+func (u *ResumableUsecaseImpl) get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.ResumableUpload, error) {
+	upload, err := u.repo.GetUpload(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+	if upload.UserID != userID {
+		return nil, ErrNotFound
+	}
+	if upload.CompletedAt == nil && time.Now().After(upload.ExpiresAt) {
+		return nil, ErrExpired
+	}
+
+	return upload, nil
+}
+
+func (u *ResumableUsecaseImpl) Write(ctx context.Context, userID uuid.UUID, id uuid.UUID, offset int64, data io.Reader) (*models.ResumableUpload, error) {
+	unlock := u.lock(id)
+	defer unlock()
+
+	upload, err := u.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return nil, ErrOffsetMismatch
+	}
+	if upload.CompletedAt != nil {
+		if n, _ := io.CopyN(io.Discard, data, 1); n > 0 {
+			return nil, ErrTooLarge
+		}
+		return upload, nil
+	}
+
+	file, err := os.OpenFile(u.path(id), os.O_WRONLY, 0)
+	if err != nil {
+		u.logger.Error("failed to open upload file", "id", id, "error", err)
+		return nil, err
+	}
+
+	remaining := upload.Length - offset
+	written, copyErr := io.Copy(io.NewOffsetWriter(file, offset), io.LimitReader(data, remaining+1))
+	if written > remaining {
+		written, copyErr = 0, ErrTooLarge
+	}
+	// drop anything past what was received, also left by a write that
+	// failed halfway
+	err = file.Truncate(offset + written)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		u.logger.Error("failed to write upload file", "id", id, "error", err)
+		return nil, err
+	}
+
+	if written > 0 {
+		upload.Offset += written
+		if err := u.repo.UpdateUpload(ctx, upload); err != nil {
+			u.logger.Debug(err.Error())
+			return nil, err
+		}
+	}
+	if copyErr != nil {
+		u.logger.Debug("upload chunk cut off", "id", id, "offset", upload.Offset, "error", copyErr)
+		return nil, copyErr
+	}
+
+	if upload.Offset == upload.Length {
+		if err := u.complete(ctx, upload); err != nil {
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// complete checks the type of the finished file and moves it to the blob
+// store under the hex SHA-256 of its content, unless the store has it
+// already. A file that is rejected is deleted along with its upload.
+func (u *ResumableUsecaseImpl) complete(ctx context.Context, upload *models.ResumableUpload) error {
+	partial := u.path(upload.ID)
+
+	file, err := os.Open(partial)
+	if err != nil {
+		u.logger.Error("failed to open upload file", "id", upload.ID, "error", err)
+		return err
+	}
+	defer file.Close()
+
+	detected, checksum, err := filecheck.Inspect(file)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		u.logger.Error("failed to read upload file", "id", upload.ID, "error", err)
+		return err
+	}
+	if !filecheck.AllowedType(detected, env.AttachmentTypes) {
+		u.remove(ctx, upload)
+		return ErrUnsupportedType
+	}
+
+	key := checksum + detected.Extension()
+	stored, err := storage.PutOnce(storage.WithOwner(ctx, upload.UserID.String()), u.blob, key, file, storage.PutOptions{
+		ContentType: detected.String(),
+	})
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		u.remove(ctx, upload)
+		return err
+	}
+	if err != nil {
+		u.logger.Error("failed to store upload", "id", upload.ID, "error", err)
+		return err
+	}
+
+	now := time.Now()
+	upload.Key = key
+	upload.ContentType = detected.String()
+	upload.Checksum = checksum
+	upload.CompletedAt = &now
+	if err := u.repo.UpdateUpload(ctx, upload); err != nil {
+		u.logger.Debug(err.Error())
+		if stored {
+			u.blob.Delete(ctx, key)
+		}
+		return err
+	}
+
+	if err := os.Remove(partial); err != nil {
+		u.logger.Warn("failed to remove upload file", "id", upload.ID, "error", err)
+	}
+
+	return nil
+}
+
+func (u *ResumableUsecaseImpl) Terminate(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	unlock := u.lock(id)
+	defer unlock()
+
+	upload, err := u.get(ctx, userID, id)
+	if err != nil && !errors.Is(err, ErrExpired) {
+		return err
+	}
+	if upload == nil {
+		upload, err = u.repo.GetUpload(ctx, id)
+		if err != nil {
+			u.logger.Debug(err.Error())
+			return err
+		}
+	}
+
+	return u.remove(ctx, upload)
+}
+
+func (u *ResumableUsecaseImpl) Cleanup(ctx context.Context) error {
+	uploads, err := u.repo.GetExpired(ctx, time.Now())
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	for _, upload := range uploads {
+		unlock := u.lock(upload.ID)
+		err := u.remove(ctx, upload)
+		unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(uploads) > 0 {
+		u.logger.Info("expired uploads removed", "uploads", len(uploads))
+	}
+	return nil
+}
+
+func (u *ResumableUsecaseImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := u.Cleanup(ctx); err != nil {
+				u.logger.Error("failed to clean up uploads", "error", err)
+			}
+		}
+	}
+}
+
+// remove deletes an upload and its data. The stored file is only deleted
+// along with the record, since a todo that attached the upload has taken
+// the record and kept the file, and while nothing else references it.
+func (u *ResumableUsecaseImpl) remove(ctx context.Context, upload *models.ResumableUpload) error {
+	if err := os.Remove(u.path(upload.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		u.logger.Error("failed to remove upload file", "id", upload.ID, "error", err)
+		return err
+	}
+
+	deleted, err := u.repo.DeleteUpload(ctx, upload.ID)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	u.locks.Delete(upload.ID)
+	if !deleted || upload.Key == "" {
+		return nil
+	}
+
+	// a file left behind is swept as an orphan later
+	referenced, err := u.repo.IsReferenced(ctx, upload.Key)
+	if err != nil {
+		u.logger.Warn("failed to check references of upload", "id", upload.ID, "key", upload.Key, "error", err)
+		return nil
+	}
+	if !referenced {
+		if err := u.blob.Delete(ctx, upload.Key); err != nil {
+			u.logger.Warn("failed to delete upload", "id", upload.ID, "key", upload.Key, "error", err)
+		}
+	}
+	return nil
+}
+
+func (u *ResumableUsecaseImpl) path(id uuid.UUID) string {
+	return filepath.Join(u.dir, id.String())
+}
+
+// lock holds the lock of the upload until the returned function is called.
+// Locks are only shared within this process.
+func (u *ResumableUsecaseImpl) lock(id uuid.UUID) func() {
+	mu, _ := u.locks.LoadOrStore(id, new(sync.Mutex))
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/bus"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ActivityHandler interface {
+	TodoCreated(ctx context.Context, event models.TodoCreated, metadata bus.Metadata) error
+	TodoUpdated(ctx context.Context, event models.TodoChange, metadata bus.Metadata) error
+	CommentCreated(ctx context.Context, event models.CommentCreated, metadata bus.Metadata) error
+	GetActivities(c *fiber.Ctx) error
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"practice/internal/todo/usecase"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ActivityHandlerImpl struct {
+	usecase usecase.ActivityUsecase
+	logger  *logger.Logger
+}
+
+func NewActivityHandler(usecase usecase.ActivityUsecase, logger *logger.Logger) ActivityHandler {
+	return &ActivityHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+// TodoCreated, like TodoUpdated and CommentCreated, turns a bus event into
+// timeline entries.
+func (h *ActivityHandlerImpl) TodoCreated(ctx context.Context, event models.TodoCreated, metadata bus.Metadata) error {
+	return h.usecase.RecordCreated(ctx, event.Todo)
+}
+
+func (h *ActivityHandlerImpl) TodoUpdated(ctx context.Context, event models.TodoChange, metadata bus.Metadata) error {
+	return h.usecase.RecordChange(ctx, &event)
+}
+
+func (h *ActivityHandlerImpl) CommentCreated(ctx context.Context, event models.CommentCreated, metadata bus.Metadata) error {
+	return h.usecase.RecordComment(ctx, event.Comment)
+}
+
+func (h *ActivityHandlerImpl) GetActivities(c *fiber.Ctx) error {
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	var pagParams models.PaginationRequest
+	if err := c.QueryParser(&pagParams); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid pagination params",
+		})
+	}
+
+	params := &pagination.PaginationParams{
+		Page:  pagParams.Page,
+		Limit: pagParams.Limit,
+		Sort:  pagParams.Sort,
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	activities, err := h.usecase.GetActivities(c.Context(), params, userID, todoID)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    activities,
+	})
+}
@@ -0,0 +1,9 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+type AttachmentHandler interface {
+	GetAttachments(c *fiber.Ctx) error
+	AddAttachments(c *fiber.Ctx) error
+	DeleteAttachment(c *fiber.Ctx) error
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"practice/internal/todo/usecase"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AttachmentHandlerImpl struct {
+	usecase usecase.AttachmentUsecase
+	logger  *logger.Logger
+}
+
+func NewAttachmentHandler(usecase usecase.AttachmentUsecase, logger *logger.Logger) AttachmentHandler {
+	return &AttachmentHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+func (h *AttachmentHandlerImpl) GetAttachments(c *fiber.Ctx) error {
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	attachments, err := h.usecase.GetAttachments(c.Context(), userID, todoID)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    attachments,
+	})
+}
+
+// AddAttachments attaches the files uploaded in the multipart "files" field
+// or, given a JSON body, the finished resumable uploads it lists.
+func (h *AttachmentHandlerImpl) AddAttachments(c *fiber.Ctx) error {
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	uploads, _ := c.Locals("uploads").([]middleware.UploadedFile)
+	if len(uploads) == 0 && c.Is("json") {
+		return h.attachUploads(c, userID, todoID)
+	}
+	if len(uploads) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "no files uploaded",
+		})
+	}
+
+	attachments := make([]*models.Attachment, len(uploads))
+	for i, upload := range uploads {
+		attachments[i] = &models.Attachment{
+			Filename:    upload.Filename,
+			Key:         upload.Key,
+			Size:        upload.Size,
+			ContentType: upload.ContentType,
+			Checksum:    upload.Checksum,
+		}
+	}
+
+	attachments, err = h.usecase.AddAttachments(c.Context(), userID, todoID, attachments)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    attachments,
+	})
+}
+
+func (h *AttachmentHandlerImpl) attachUploads(c *fiber.Ctx, userID uuid.UUID, todoID uuid.UUID) error {
+	request := new(models.AttachmentRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+
+	attachments, err := h.usecase.AttachUploads(c.Context(), userID, todoID, request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    attachments,
+	})
+}
+
+func (h *AttachmentHandlerImpl) DeleteAttachment(c *fiber.Ctx) error {
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	if err := h.usecase.DeleteAttachment(c.Context(), userID, todoID, attachmentID); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+	})
+}
@@ -0,0 +1,9 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+type CalendarHandler interface {
+	CreateToken(c *fiber.Ctx) error
+	RevokeToken(c *fiber.Ctx) error
+	GetFeed(c *fiber.Ctx) error
+}
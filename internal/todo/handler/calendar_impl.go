@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"practice/internal/todo/usecase"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarHandlerImpl struct {
+	usecase usecase.CalendarUsecase
+	logger  *logger.Logger
+}
+
+func NewCalendarHandler(usecase usecase.CalendarUsecase, logger *logger.Logger) CalendarHandler {
+	return &CalendarHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+func (h *CalendarHandlerImpl) CreateToken(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	token, err := h.usecase.CreateToken(c.Context(), userID)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data": fiber.Map{
+			"token": token,
+			"url":   c.BaseURL() + "/api/calendar/" + token + ".ics",
+		},
+	})
+}
+
+func (h *CalendarHandlerImpl) RevokeToken(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	if err := h.usecase.RevokeToken(c.Context(), userID); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
+// GetFeed serves the iCalendar feed. It is authenticated by the secret
+// token in the URL instead of a JWT so calendar apps can subscribe to it.
+func (h *CalendarHandlerImpl) GetFeed(c *fiber.Ctx) error {
+	var feed bytes.Buffer
+	if err := h.usecase.WriteFeed(c.Context(), c.Params("token"), &feed); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+
+	return c.Status(fiber.StatusOK).Send(feed.Bytes())
+}
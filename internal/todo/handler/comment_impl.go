@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"practice/internal/todo/usecase"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CommentHandlerImpl struct {
+	usecase usecase.CommentUsecase
+	logger  *logger.Logger
+	event   *bus.EventBus
+}
+
+func NewCommentHandler(usecase usecase.CommentUsecase, logger *logger.Logger, event *bus.EventBus) CommentHandler {
+	return &CommentHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+		event:   event,
+	}
+}
+
+func (h *CommentHandlerImpl) AddComment(c *fiber.Ctx) error {
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.CommentRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+	request.TodoID = todoID
+	request.UserID = userID
+
+	comment, mentions, err := h.usecase.AddComment(c.Context(), request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	ctx := eventContext(c)
+	bus.Publish(h.event, ctx, models.CommentCreated{Comment: comment})
+	h.publishMentions(ctx, mentions)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    comment,
+	})
+}
+
+func (h *CommentHandlerImpl) UpdateComment(c *fiber.Ctx) error {
+	commentID, err := uuid.Parse(c.Params("commentId"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.CommentRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+	request.UserID = userID
+
+	comment, mentions, err := h.usecase.UpdateComment(c.Context(), commentID, request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	ctx := eventContext(c)
+	bus.Publish(h.event, ctx, models.CommentUpdated{Comment: comment})
+	h.publishMentions(ctx, mentions)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    comment,
+	})
+}
+
+func (h *CommentHandlerImpl) GetComments(c *fiber.Ctx) error {
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	var pagParams models.PaginationRequest
+	if err := c.QueryParser(&pagParams); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid pagination params",
+		})
+	}
+
+	params := &pagination.PaginationParams{
+		Page:  pagParams.Page,
+		Limit: pagParams.Limit,
+		Sort:  pagParams.Sort,
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	comments, err := h.usecase.GetComments(c.Context(), params, userID, todoID)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    comments,
+	})
+}
+
+func (h *CommentHandlerImpl) DeleteComment(c *fiber.Ctx) error {
+	commentID, err := uuid.Parse(c.Params("commentId"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	if err := h.usecase.DeleteComment(c.Context(), userID, commentID); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	todoID, _ := uuid.Parse(c.Params("id"))
+	bus.Publish(h.event, eventContext(c), models.CommentDeleted{ID: commentID, TodoID: todoID, UserID: userID})
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
+func (h *CommentHandlerImpl) publishMentions(ctx context.Context, mentions []models.CommentMention) {
+	for _, mention := range mentions {
+		bus.Publish(h.event, ctx, mention)
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"practice/internal/todo/usecase"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errorStatuses = []middleware.ErrorStatus{
+	{Err: usecase.ErrInvalidParent, Status: fiber.StatusBadRequest},
+	{Err: usecase.ErrInvalidAnchor, Status: fiber.StatusBadRequest},
+	{Err: usecase.ErrInvalidLang, Status: fiber.StatusBadRequest},
+	{Err: usecase.ErrInvalidFormat, Status: fiber.StatusBadRequest},
+	{Err: usecase.ErrInvalidImport, Status: fiber.StatusBadRequest},
+	{Err: usecase.ErrInvalidRange, Status: fiber.StatusBadRequest},
+	{Err: usecase.ErrInvalidImages, Status: fiber.StatusBadRequest},
+	{Err: usecase.ErrNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrCommentNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrTemplateNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrFeedNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrImageNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrAttachmentNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrUploadNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrTooManyImages, Status: fiber.StatusRequestEntityTooLarge},
+	{Err: usecase.ErrTooManyAttachments, Status: fiber.StatusRequestEntityTooLarge},
+	{Err: storage.ErrQuotaExceeded, Status: fiber.StatusRequestEntityTooLarge},
+	{Err: usecase.ErrForbidden, Status: fiber.StatusForbidden},
+}
+
+// fail maps usecase errors to their HTTP responses.
+func fail(c *fiber.Ctx, logger *logger.Logger, err error) error {
+	return middleware.Fail(c, logger, err, errorStatuses)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// eventContext returns the request context carrying the current user and
+// the request ID as metadata for the events published while handling it.
+func eventContext(c *fiber.Ctx) context.Context {
+	var ctx context.Context = c.Context()
+
+	if userID, err := middleware.UserID(c); err == nil {
+		ctx = bus.WithActor(ctx, userID.String())
+	}
+
+	requestID := c.Get(fiber.HeaderXRequestID)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+
+	return bus.WithCorrelationID(ctx, requestID)
+}
+
+// publishImages has the images of a todo or template processed, once the
+// request storing them has succeeded.
+func publishImages(event *bus.EventBus, c *fiber.Ctx, userID uuid.UUID, keys []string) {
+	ctx := eventContext(c)
+	for _, key := range keys {
+		bus.Publish(event, ctx, models.ImageUploaded{Key: key, UserID: userID})
+	}
+}
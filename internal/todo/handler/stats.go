@@ -0,0 +1,7 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+type StatsHandler interface {
+	GetStats(c *fiber.Ctx) error
+}
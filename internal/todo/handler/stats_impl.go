@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"practice/internal/todo/usecase"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StatsHandlerImpl struct {
+	usecase usecase.StatsUsecase
+	logger  *logger.Logger
+}
+
+func NewStatsHandler(usecase usecase.StatsUsecase, logger *logger.Logger) StatsHandler {
+	return &StatsHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+func (h *StatsHandlerImpl) GetStats(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TodoStatsRequest)
+	if err := c.QueryParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid stats params",
+		})
+	}
+	request.UserID = userID
+
+	stats, err := h.usecase.GetStats(c.Context(), request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    stats,
+	})
+}
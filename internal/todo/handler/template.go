@@ -0,0 +1,12 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+type TemplateHandler interface {
+	GetTemplates(c *fiber.Ctx) error
+	GetTemplate(c *fiber.Ctx) error
+	AddTemplate(c *fiber.Ctx) error
+	AddTemplateFromTodo(c *fiber.Ctx) error
+	DeleteTemplate(c *fiber.Ctx) error
+	InstantiateTemplate(c *fiber.Ctx) error
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"practice/internal/todo/usecase"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TemplateHandlerImpl struct {
+	usecase usecase.TemplateUsecase
+	logger  *logger.Logger
+	event   *bus.EventBus
+}
+
+func NewTemplateHandler(usecase usecase.TemplateUsecase, logger *logger.Logger, event *bus.EventBus) TemplateHandler {
+	return &TemplateHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+		event:   event,
+	}
+}
+
+func (h *TemplateHandlerImpl) AddTemplate(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TodoTemplateRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+	request.UserID = userID
+
+	if filenames, ok := c.Locals("filenames").([]string); ok {
+		request.Images = filenames
+	}
+
+	template, err := h.usecase.AddTemplate(c.Context(), request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	publishImages(h.event, c, userID, template.Images)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    template,
+	})
+}
+
+func (h *TemplateHandlerImpl) AddTemplateFromTodo(c *fiber.Ctx) error {
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TemplateFromTodoRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+
+	template, err := h.usecase.AddTemplateFromTodo(c.Context(), userID, todoID, request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	publishImages(h.event, c, userID, template.Images)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    template,
+	})
+}
+
+func (h *TemplateHandlerImpl) GetTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	template, err := h.usecase.GetTemplate(c.Context(), userID, id)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    template,
+	})
+}
+
+func (h *TemplateHandlerImpl) GetTemplates(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	var pagParams models.PaginationRequest
+	if err := c.QueryParser(&pagParams); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid pagination params",
+		})
+	}
+
+	params := &pagination.PaginationParams{
+		Page:  pagParams.Page,
+		Limit: pagParams.Limit,
+		Sort:  pagParams.Sort,
+	}
+
+	templates, err := h.usecase.GetTemplates(c.Context(), params, userID)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    templates,
+	})
+}
+
+func (h *TemplateHandlerImpl) DeleteTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	if err := h.usecase.DeleteTemplate(c.Context(), userID, id); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
+func (h *TemplateHandlerImpl) InstantiateTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TemplateInstantiateRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(request); err != nil {
+			h.logger.Debug(err.Error())
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "invalid request",
+			})
+		}
+	}
+
+	todo, err := h.usecase.InstantiateTemplate(eventContext(c), userID, id, request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	publishImages(h.event, c, userID, todo.Images)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    todo,
+	})
+}
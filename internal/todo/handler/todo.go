@@ -1,11 +1,25 @@
 package handler
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"practice/pkg/bus"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 type TodoHandler interface {
+	bus.EventHandler
 	GetTodos(c *fiber.Ctx) error
 	GetTodo(c *fiber.Ctx) error
 	AddTodo(c *fiber.Ctx) error
 	UpdateTodo(c *fiber.Ctx) error
+	GetTodoTree(c *fiber.Ctx) error
+	MoveTodo(c *fiber.Ctx) error
 	DeleteTodo(c *fiber.Ctx) error
+	ArchiveTodo(c *fiber.Ctx) error
+	UnarchiveTodo(c *fiber.Ctx) error
+	DuplicateTodo(c *fiber.Ctx) error
+	BulkTodos(c *fiber.Ctx) error
+	AddImages(c *fiber.Ctx) error
+	RemoveImage(c *fiber.Ctx) error
+	ReorderImages(c *fiber.Ctx) error
 }
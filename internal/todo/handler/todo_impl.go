@@ -1,15 +1,15 @@
 package handler
 
 import (
-	"fmt"
+	"context"
 	"practice/internal/todo/usecase"
 	"practice/models"
 	"practice/pkg/bus"
 	"practice/pkg/logger"
+	"practice/pkg/middleware"
 	"practice/pkg/pagination"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -28,7 +28,7 @@ func NewTodoHandler(usecase usecase.TodoUsecase, logger *logger.Logger, event *b
 }
 
 func (h *TodoHandlerImpl) Handle(event bus.Event) {
-	h.logger.Info("Todo event: %s - %v", event.Type, event.Payload)
+	h.logger.Info("todo event", "type", event.Type, "id", event.Metadata.ID, "actor", event.Metadata.Actor)
 }
 
 func (h *TodoHandlerImpl) AddTodo(c *fiber.Ctx) error {
@@ -40,13 +40,7 @@ func (h *TodoHandlerImpl) AddTodo(c *fiber.Ctx) error {
 		})
 	}
 
-	userLocal := c.Locals("user")
-	filenames := c.Locals("filenames")
-
-	userMap := userLocal.(jwt.MapClaims)
-	id := userMap["id"].(string)
-
-	uid, err := uuid.Parse(id)
+	uid, err := middleware.UserID(c)
 	if err != nil {
 		h.logger.Debug(err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -54,23 +48,17 @@ func (h *TodoHandlerImpl) AddTodo(c *fiber.Ctx) error {
 		})
 	}
 
-	request.UserID = uid
-	request.Images = filenames.([]string)
+	filenames, _ := c.Locals("filenames").([]string)
 
-	fmt.Println("filenames", filenames)
-	fmt.Println("request", request)
+	request.UserID = uid
+	request.Images = filenames
 
-	if err := h.usecase.AddTodo(c.Context(), request); err != nil {
-		h.logger.Error(err.Error())
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "internal server error",
-		})
+	_, err = h.usecase.AddTodo(eventContext(c), request)
+	if err != nil {
+		return fail(c, h.logger, err)
 	}
 
-	h.event.Publish(bus.Event{
-		Type:    "todo.created",
-		Payload: request,
-	})
+	publishImages(h.event, c, uid, request.Images)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "success",
@@ -107,15 +95,17 @@ func (h *TodoHandlerImpl) UpdateTodo(c *fiber.Ctx) error {
 	request.ID = uid
 	// request.UpdatedBy = user.ID
 
-	if err := h.usecase.UpdateTodo(c.Context(), request); err != nil {
-		h.logger.Error(err.Error())
-		return c.Next()
+	actorID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
 	}
 
-	h.event.Publish(bus.Event{
-		Type:    "todo.updated",
-		Payload: request,
-	})
+	if err := h.usecase.UpdateTodo(eventContext(c), actorID, request); err != nil {
+		return fail(c, h.logger, err)
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "success",
@@ -123,18 +113,25 @@ func (h *TodoHandlerImpl) UpdateTodo(c *fiber.Ctx) error {
 }
 
 func (h *TodoHandlerImpl) GetTodo(c *fiber.Ctx) error {
-	id := c.Params("id", "")
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
 
-	uuid, err := uuid.Parse(id)
+	userID, err := middleware.UserID(c)
 	if err != nil {
 		h.logger.Debug(err.Error())
-		return c.Next()
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
 	}
 
-	todo, err := h.usecase.GetTodo(c.Context(), uuid)
+	todo, err := h.usecase.GetTodo(c.Context(), userID, id)
 	if err != nil {
-		h.logger.Error(err.Error())
-		return c.Next()
+		return fail(c, h.logger, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -144,8 +141,7 @@ func (h *TodoHandlerImpl) GetTodo(c *fiber.Ctx) error {
 }
 
 func (h *TodoHandlerImpl) GetTodos(c *fiber.Ctx) error {
-	userMap := c.Locals("user").(jwt.MapClaims)
-	uid, err := uuid.Parse(userMap["id"].(string))
+	uid, err := middleware.UserID(c)
 	if err != nil {
 		h.logger.Debug(err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -167,10 +163,17 @@ func (h *TodoHandlerImpl) GetTodos(c *fiber.Ctx) error {
 		Sort:  pagParams.Sort,
 	}
 
-	todos, err := h.usecase.GetTodos(c.Context(), params, uid, "todo", c.Query("todo"))
+	filter := new(models.TodoFilter)
+	if err := c.QueryParser(filter); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid filter params",
+		})
+	}
+
+	todos, err := h.usecase.GetTodos(c.Context(), params, uid, filter)
 	if err != nil {
-		h.logger.Error(err.Error())
-		return c.Next()
+		return fail(c, h.logger, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -179,23 +182,320 @@ func (h *TodoHandlerImpl) GetTodos(c *fiber.Ctx) error {
 	})
 }
 
+func (h *TodoHandlerImpl) GetTodoTree(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	tree, err := h.usecase.GetTodoTree(c.Context(), userID, id)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    tree,
+	})
+}
+
+func (h *TodoHandlerImpl) MoveTodo(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TodoMoveRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+
+	if err := h.usecase.MoveTodo(c.Context(), userID, id, request); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	bus.Publish(h.event, eventContext(c), models.TodoMoved{ID: id, UserID: userID})
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
 func (h *TodoHandlerImpl) DeleteTodo(c *fiber.Ctx) error {
-	id := c.Params("id", "")
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
 
-	uuid, err := uuid.Parse(id)
+	userID, err := middleware.UserID(c)
 	if err != nil {
 		h.logger.Debug(err.Error())
-		return c.Next()
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
 	}
 
-	user := c.Locals("user").(models.User)
+	mode := c.Query("mode", models.DeleteReparent)
+	if mode != models.DeleteReparent && mode != models.DeleteCascade {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid delete mode",
+		})
+	}
 
-	if err := h.usecase.DeleteTodo(c.Context(), user.ID, uuid); err != nil {
-		h.logger.Error(err.Error())
-		return c.Next()
+	if err := h.usecase.DeleteTodo(c.Context(), userID, id, mode); err != nil {
+		return fail(c, h.logger, err)
 	}
 
+	bus.Publish(h.event, eventContext(c), models.TodoDeleted{ID: id, UserID: userID, Mode: mode})
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "success",
 	})
 }
+
+func (h *TodoHandlerImpl) DuplicateTodo(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TodoDuplicateRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(request); err != nil {
+			h.logger.Debug(err.Error())
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "invalid request",
+			})
+		}
+	}
+
+	todo, err := h.usecase.DuplicateTodo(eventContext(c), userID, id, request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	publishImages(h.event, c, userID, todo.Images)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    todo,
+	})
+}
+
+func (h *TodoHandlerImpl) BulkTodos(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TodoBulkRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+	request.UserID = userID
+
+	result, err := h.usecase.BulkTodos(eventContext(c), request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	bus.Publish(h.event, eventContext(c), *result)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    result,
+	})
+}
+
+func (h *TodoHandlerImpl) ArchiveTodo(c *fiber.Ctx) error {
+	return h.setArchived(c, h.usecase.ArchiveTodo, func(todo *models.Todo) bus.Typed {
+		return models.TodoArchived{Todo: todo}
+	})
+}
+
+func (h *TodoHandlerImpl) UnarchiveTodo(c *fiber.Ctx) error {
+	return h.setArchived(c, h.usecase.UnarchiveTodo, func(todo *models.Todo) bus.Typed {
+		return models.TodoUnarchived{Todo: todo}
+	})
+}
+
+func (h *TodoHandlerImpl) setArchived(
+	c *fiber.Ctx,
+	archive func(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.Todo, error),
+	event func(todo *models.Todo) bus.Typed,
+) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	todo, err := archive(c.Context(), userID, id)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	h.event.Publish(bus.NewEvent(eventContext(c), event(todo)))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    todo,
+	})
+}
+
+// AddImages appends the images uploaded in the multipart "images" field.
+func (h *TodoHandlerImpl) AddImages(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	images, _ := c.Locals("filenames").([]string)
+	if len(images) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "no images uploaded",
+		})
+	}
+
+	todo, err := h.usecase.AddImages(eventContext(c), userID, id, images)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	publishImages(h.event, c, userID, images)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    todo,
+	})
+}
+
+func (h *TodoHandlerImpl) RemoveImage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	todo, err := h.usecase.RemoveImage(eventContext(c), userID, id, c.Params("imageId"))
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    todo,
+	})
+}
+
+func (h *TodoHandlerImpl) ReorderImages(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	request := new(models.TodoImagesRequest)
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+		})
+	}
+
+	todo, err := h.usecase.ReorderImages(eventContext(c), userID, id, request)
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    todo,
+	})
+}
@@ -0,0 +1,8 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+type TransferHandler interface {
+	ExportTodos(c *fiber.Ctx) error
+	ImportTodos(c *fiber.Ctx) error
+}
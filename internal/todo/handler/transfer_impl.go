@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"practice/internal/todo/usecase"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var exportTypes = map[string]struct {
+	contentType string
+	extension   string
+}{
+	models.FormatJSON:     {fiber.MIMEApplicationJSONCharsetUTF8, "json"},
+	models.FormatCSV:      {"text/csv; charset=utf-8", "csv"},
+	models.FormatMarkdown: {"text/markdown; charset=utf-8", "md"},
+	models.FormatICal:     {"text/calendar; charset=utf-8", "ics"},
+}
+
+type TransferHandlerImpl struct {
+	usecase usecase.TransferUsecase
+	logger  *logger.Logger
+	event   *bus.EventBus
+}
+
+func NewTransferHandler(usecase usecase.TransferUsecase, logger *logger.Logger, event *bus.EventBus) TransferHandler {
+	return &TransferHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+		event:   event,
+	}
+}
+
+// ExportTodos streams the caller's todos, filtered like GetTodos.
+func (h *TransferHandlerImpl) ExportTodos(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	format := c.Query("format", models.FormatJSON)
+	exportType, ok := exportTypes[format]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": usecase.ErrInvalidFormat.Error(),
+		})
+	}
+
+	filter := new(models.TodoFilter)
+	if err := c.QueryParser(filter); err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid filter params",
+		})
+	}
+
+	if err := h.usecase.CheckFilter(filter); err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	c.Set(fiber.HeaderContentType, exportType.contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="todos.`+exportType.extension+`"`)
+
+	// the stream is written after the handler returns, so it must not use
+	// the request context
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.usecase.ExportTodos(context.Background(), userID, filter, format, w); err != nil {
+			h.logger.Error(err.Error())
+		}
+		w.Flush()
+	})
+
+	return nil
+}
+
+// ImportTodos accepts the export formats either as the raw body or as a
+// multipart "file". The format comes from ?format= or the file extension.
+func (h *TransferHandlerImpl) ImportTodos(c *fiber.Ctx) error {
+	userID, err := middleware.UserID(c)
+	if err != nil {
+		h.logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid ID",
+		})
+	}
+
+	format := c.Query("format")
+
+	var body io.Reader = bytes.NewReader(c.Body())
+	if file, err := c.FormFile("file"); err == nil {
+		if format == "" {
+			format = formatFromFilename(file.Filename)
+		}
+
+		opened, err := file.Open()
+		if err != nil {
+			h.logger.Error(err.Error())
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "invalid file",
+			})
+		}
+		defer opened.Close()
+		body = opened
+	}
+
+	if format == "" {
+		format = models.FormatJSON
+	}
+
+	result, err := h.usecase.ImportTodos(eventContext(c), userID, format, body, c.QueryBool("dry_run"))
+	if err != nil {
+		return fail(c, h.logger, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "success",
+		"data":    result,
+	})
+}
+
+func formatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return models.FormatJSON
+	case ".csv":
+		return models.FormatCSV
+	case ".md", ".markdown":
+		return models.FormatMarkdown
+	case ".ics", ".ical":
+		return models.FormatICal
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type ActivityRepo interface {
+	AddActivities(ctx context.Context, activities []*models.TodoActivity) error
+	GetActivities(ctx context.Context, params *pagination.Pagination, todoID uuid.UUID) ([]*models.TodoActivity, error)
+}
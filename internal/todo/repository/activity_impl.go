@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ActivityRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewActivityRepo(db *gorm.DB) ActivityRepo {
+	return &ActivityRepoImpl{
+		db: db,
+	}
+}
+
+func (r *ActivityRepoImpl) AddActivities(ctx context.Context, activities []*models.TodoActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Model(&models.TodoActivity{}).Create(activities).Error
+}
+
+func (r *ActivityRepoImpl) GetActivities(ctx context.Context, params *pagination.Pagination, todoID uuid.UUID) ([]*models.TodoActivity, error) {
+	var activities []*models.TodoActivity
+
+	query := r.db.WithContext(ctx).Where("todo_id = ?", todoID)
+
+	paginated, err := pagination.Paginate(&models.TodoActivity{}, params, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := paginated.Preload("Actor").Preload("Comment").Preload("Comment.User").Find(&activities).Error; err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+
+	"github.com/google/uuid"
+)
+
+type AttachmentRepo interface {
+	AddAttachments(ctx context.Context, attachments []*models.Attachment) error
+	// GetResumableUploads returns the user's completed resumable uploads
+	// among ids.
+	GetResumableUploads(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*models.ResumableUpload, error)
+	// AttachUploads adds the attachments and deletes the resumable uploads
+	// they were made from. It fails with gorm.ErrRecordNotFound if one of
+	// the uploads is gone.
+	AttachUploads(ctx context.Context, attachments []*models.Attachment, uploads []uuid.UUID) error
+	GetAttachment(ctx context.Context, uuid uuid.UUID) (*models.Attachment, error)
+	GetAttachments(ctx context.Context, todoID uuid.UUID) ([]*models.Attachment, error)
+	CountAttachments(ctx context.Context, todoID uuid.UUID) (int64, error)
+	DeleteAttachment(ctx context.Context, uuid uuid.UUID) error
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepo(db *gorm.DB) AttachmentRepo {
+	return &AttachmentRepoImpl{
+		db: db,
+	}
+}
+
+func (r *AttachmentRepoImpl) AddAttachments(ctx context.Context, attachments []*models.Attachment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Attachment{}).Create(attachments).Error; err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			if err := attachFiles(tx, attachment.UserID, []string{attachment.Key}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *AttachmentRepoImpl) GetResumableUploads(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*models.ResumableUpload, error) {
+	var uploads []*models.ResumableUpload
+	err := r.db.WithContext(ctx).Model(&models.ResumableUpload{}).
+		Where("id IN ? AND user_id = ? AND completed_at IS NOT NULL", ids, userID).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// AttachUploads claims the uploads by deleting them in the same
+// transaction, so an upload expiring meanwhile cannot take the file along.
+func (r *AttachmentRepoImpl) AttachUploads(ctx context.Context, attachments []*models.Attachment, uploads []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id IN ? AND completed_at IS NOT NULL", uploads).Delete(&models.ResumableUpload{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(uploads)) {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Model(&models.Attachment{}).Create(attachments).Error; err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			if err := attachFiles(tx, attachment.UserID, []string{attachment.Key}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *AttachmentRepoImpl) GetAttachment(ctx context.Context, uuid uuid.UUID) (*models.Attachment, error) {
+	var attachment *models.Attachment
+	err := r.db.WithContext(ctx).Model(&models.Attachment{}).First(&attachment, "id = ?", uuid).Error
+	return attachment, err
+}
+
+// GetAttachments returns the attachments of the todo, oldest first.
+func (r *AttachmentRepoImpl) GetAttachments(ctx context.Context, todoID uuid.UUID) ([]*models.Attachment, error) {
+	var attachments []*models.Attachment
+	err := r.db.WithContext(ctx).Model(&models.Attachment{}).
+		Where("todo_id = ?", todoID).
+		Order("created_at, filename").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *AttachmentRepoImpl) CountAttachments(ctx context.Context, todoID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Attachment{}).Where("todo_id = ?", todoID).Count(&count).Error
+	return count, err
+}
+
+func (r *AttachmentRepoImpl) DeleteAttachment(ctx context.Context, uuid uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", uuid).Delete(&models.Attachment{}).Error
+}
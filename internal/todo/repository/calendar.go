@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+
+	"github.com/google/uuid"
+)
+
+type CalendarRepo interface {
+	SaveFeed(ctx context.Context, feed *models.CalendarFeed) error
+	GetFeedByTokenHash(ctx context.Context, tokenHash string) (*models.CalendarFeed, error)
+	DeleteFeed(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CalendarRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepo(db *gorm.DB) CalendarRepo {
+	return &CalendarRepoImpl{
+		db: db,
+	}
+}
+
+// SaveFeed creates the user's feed or replaces its token.
+func (r *CalendarRepoImpl) SaveFeed(ctx context.Context, feed *models.CalendarFeed) error {
+	return r.db.WithContext(ctx).Model(&models.CalendarFeed{}).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "updated_at"}),
+	}).Create(feed).Error
+}
+
+func (r *CalendarRepoImpl) GetFeedByTokenHash(ctx context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	var feed *models.CalendarFeed
+	err := r.db.WithContext(ctx).Model(&models.CalendarFeed{}).First(&feed, "token_hash = ?", tokenHash).Error
+	return feed, err
+}
+
+func (r *CalendarRepoImpl) DeleteFeed(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.CalendarFeed{}).Where("user_id = ?", userID).Delete(&models.CalendarFeed{}).Error
+}
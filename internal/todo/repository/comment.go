@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type CommentRepo interface {
+	AddComment(ctx context.Context, comment *models.Comment) error
+	UpdateComment(ctx context.Context, comment *models.Comment) error
+	GetComment(ctx context.Context, uuid uuid.UUID) (*models.Comment, error)
+	GetComments(ctx context.Context, params *pagination.Pagination, todoID uuid.UUID) ([]*models.Comment, error)
+	DeleteComment(ctx context.Context, uuid uuid.UUID) error
+	GetUsersByEmails(ctx context.Context, emails []string) ([]*models.User, error)
+}
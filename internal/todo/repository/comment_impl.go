@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommentRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewCommentRepo(db *gorm.DB) CommentRepo {
+	return &CommentRepoImpl{
+		db: db,
+	}
+}
+
+func (r *CommentRepoImpl) AddComment(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Model(&models.Comment{}).Create(comment).Error
+}
+
+func (r *CommentRepoImpl) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Model(&models.Comment{}).Save(comment).Error
+}
+
+func (r *CommentRepoImpl) GetComment(ctx context.Context, uuid uuid.UUID) (*models.Comment, error) {
+	var comment *models.Comment
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).Preload("User").First(&comment, "id = ?", uuid).Error
+	return comment, err
+}
+
+func (r *CommentRepoImpl) GetComments(ctx context.Context, params *pagination.Pagination, todoID uuid.UUID) ([]*models.Comment, error) {
+	var comments []*models.Comment
+
+	query := r.db.WithContext(ctx).Where("todo_id = ?", todoID)
+
+	paginated, err := pagination.Paginate(&models.Comment{}, params, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := paginated.Preload("User").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// DeleteComment removes the comment together with its timeline entry so the
+// activity feed never points at a comment that no longer exists.
+func (r *CommentRepoImpl) DeleteComment(ctx context.Context, uuid uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("comment_id = ?", uuid).Delete(&models.TodoActivity{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", uuid).Delete(&models.Comment{}).Error
+	})
+}
+
+func (r *CommentRepoImpl) GetUsersByEmails(ctx context.Context, emails []string) ([]*models.User, error) {
+	var users []*models.User
+	if len(emails) == 0 {
+		return users, nil
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("LOWER(email) IN ?", emails).Find(&users).Error
+	return users, err
+}
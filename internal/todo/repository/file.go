@@ -0,0 +1,12 @@
+package repository
+
+import "context"
+
+// FileRepo tells which files of todos, templates and attachments are no
+// longer used, so they can be deleted.
+type FileRepo interface {
+	// UnusedFiles returns the given files that no todo, template,
+	// attachment or resumable upload references, followed by the keys of
+	// their variants.
+	UnusedFiles(ctx context.Context, keys []string) ([]string, error)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"practice/env"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type FileRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewFileRepo(db *gorm.DB) FileRepo {
+	return &FileRepoImpl{
+		db: db,
+	}
+}
+
+// UnusedFiles keeps files that someone uploaded within UPLOAD_ORPHAN_TTL
+// and has not attached yet. Uploads are shared by content, so they may be
+// about to reference the same file.
+func (r *FileRepoImpl) UnusedFiles(ctx context.Context, keys []string) ([]string, error) {
+	var unused []string
+	if len(keys) == 0 {
+		return unused, nil
+	}
+
+	cutoff := time.Now().Add(-time.Duration(env.UploadOrphanTTL) * time.Second)
+	err := r.db.WithContext(ctx).Raw(`
+		WITH unused AS (
+			SELECT DISTINCT k.key FROM unnest(?::text[]) AS k(key)
+			WHERE NOT EXISTS (SELECT 1 FROM todos WHERE k.key = ANY(images))
+				AND NOT EXISTS (SELECT 1 FROM todo_templates WHERE k.key = ANY(images))
+				AND NOT EXISTS (SELECT 1 FROM attachments WHERE attachments.key = k.key)
+				AND NOT EXISTS (SELECT 1 FROM resumable_uploads r WHERE r.key = k.key)
+				AND NOT EXISTS (SELECT 1 FROM uploads h WHERE h.key = k.key AND h.attached_at IS NULL AND h.created_at >= ?)
+		)
+		SELECT key FROM unused
+		UNION ALL
+		SELECT unnest(variants) FROM uploads WHERE key IN (SELECT key FROM unused)`,
+		pq.StringArray(keys), cutoff,
+	).Scan(&unused).Error
+
+	return unused, err
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"practice/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// attachFiles marks the uploads of images or attachments by userID as
+// referenced, so the upload sweeper keeps them. Uploads of the same files by
+// other users keep their own state.
+func attachFiles(db *gorm.DB, userID uuid.UUID, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return db.Model(&models.Upload{}).
+		Where("key IN ? AND user_id = ? AND attached_at IS NULL", keys, userID).
+		Update("attached_at", time.Now()).Error
+}
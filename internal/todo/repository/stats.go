@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StatsRepo interface {
+	GetTodoStats(ctx context.Context, userID uuid.UUID, from time.Time, to time.Time) (*models.TodoStats, error)
+	GetCompletions(ctx context.Context, userID uuid.UUID, interval string, from time.Time, to time.Time) ([]models.TodoStatsBucket, error)
+}
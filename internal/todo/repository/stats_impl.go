@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StatsRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewStatsRepo(db *gorm.DB) StatsRepo {
+	return &StatsRepoImpl{
+		db: db,
+	}
+}
+
+// GetTodoStats counts the user's todos by status in a single pass and
+// averages the completion time of those completed in [from, to). Archived
+// todos are only counted as archived.
+func (r *StatsRepoImpl) GetTodoStats(ctx context.Context, userID uuid.UUID, from time.Time, to time.Time) (*models.TodoStats, error) {
+	var stats models.TodoStats
+
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			count(*) FILTER (WHERE archived_at IS NULL) AS total,
+			count(*) FILTER (WHERE archived_at IS NULL AND status = ?) AS done,
+			count(*) FILTER (WHERE archived_at IS NULL AND status = ?) AS in_progress,
+			count(*) FILTER (WHERE archived_at IS NULL AND status = ?) AS open,
+			count(*) FILTER (WHERE archived_at IS NOT NULL) AS archived,
+			avg(extract(epoch FROM completed_at - created_at))
+				FILTER (WHERE completed_at >= ? AND completed_at < ?) AS average_completion_seconds
+		FROM todos
+		WHERE user_id = ?`,
+		models.TodoStatusDone, models.TodoStatusInProgress, models.TodoStatusOpen,
+		from, to, userID,
+	).Scan(&stats).Error
+
+	return &stats, err
+}
+
+// GetCompletions returns, per day or week in [from, to), the number of the
+// user's todos completed and of checklist items checked on them. Periods
+// without any completion are left out.
+func (r *StatsRepoImpl) GetCompletions(ctx context.Context, userID uuid.UUID, interval string, from time.Time, to time.Time) ([]models.TodoStatsBucket, error) {
+	var buckets []models.TodoStatsBucket
+
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT coalesce(t.start, i.start) AS start, coalesce(t.todos, 0) AS todos, coalesce(i.items, 0) AS items
+		FROM (
+			SELECT date_trunc(?, completed_at) AS start, count(*) AS todos
+			FROM todos
+			WHERE user_id = ? AND completed_at >= ? AND completed_at < ?
+			GROUP BY 1
+		) t
+		FULL JOIN (
+			SELECT date_trunc(?, a.created_at) AS start, count(*) AS items
+			FROM todo_activities a
+			JOIN todos ON todos.id = a.todo_id
+			WHERE todos.user_id = ? AND a.type = ? AND a.created_at >= ? AND a.created_at < ?
+			GROUP BY 1
+		) i ON i.start = t.start
+		ORDER BY 1`,
+		interval, userID, from, to,
+		interval, userID, models.ActivityItemChecked, from, to,
+	).Scan(&buckets).Error
+
+	return buckets, err
+}
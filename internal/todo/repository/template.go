@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type TemplateRepo interface {
+	AddTemplate(ctx context.Context, template *models.TodoTemplate) error
+	GetTemplate(ctx context.Context, uuid uuid.UUID) (*models.TodoTemplate, error)
+	GetTemplates(ctx context.Context, params *pagination.Pagination, userID uuid.UUID) ([]*models.TodoTemplate, error)
+	DeleteTemplate(ctx context.Context, uuid uuid.UUID) error
+}
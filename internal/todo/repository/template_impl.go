@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TemplateRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepo(db *gorm.DB) TemplateRepo {
+	return &TemplateRepoImpl{
+		db: db,
+	}
+}
+
+func (r *TemplateRepoImpl) AddTemplate(ctx context.Context, template *models.TodoTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TodoTemplate{}).Create(template).Error; err != nil {
+			return err
+		}
+		return attachFiles(tx, template.UserID, template.Images)
+	})
+}
+
+func (r *TemplateRepoImpl) GetTemplate(ctx context.Context, uuid uuid.UUID) (*models.TodoTemplate, error) {
+	var template *models.TodoTemplate
+	err := r.db.WithContext(ctx).Model(&models.TodoTemplate{}).First(&template, "id = ?", uuid).Error
+	return template, err
+}
+
+func (r *TemplateRepoImpl) GetTemplates(ctx context.Context, params *pagination.Pagination, userID uuid.UUID) ([]*models.TodoTemplate, error) {
+	var templates []*models.TodoTemplate
+
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+
+	paginated, err := pagination.Paginate(&models.TodoTemplate{}, params, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := paginated.Find(&templates).Error; err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r *TemplateRepoImpl) DeleteTemplate(ctx context.Context, uuid uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.TodoTemplate{}).Where("id = ?", uuid).Delete(&models.TodoTemplate{}).Error
+}
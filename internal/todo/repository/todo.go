@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"practice/models"
+	"practice/pkg/bus"
 	"practice/pkg/pagination"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,6 +14,18 @@ type TodoRepo interface {
 	AddTodo(ctx context.Context, todo *models.Todo) error
 	UpdateTodo(ctx context.Context, todo *models.Todo) error
 	GetTodo(ctx context.Context, uuid uuid.UUID) (*models.Todo, error)
-	GetTodos(ctx context.Context, params *pagination.Pagination, userID *uuid.UUID, key string, value ...interface{}) ([]*models.Todo, error)
-	DeleteTodo(ctx context.Context, uuid uuid.UUID) error
+	GetTodos(ctx context.Context, params *pagination.Pagination, userID *uuid.UUID, filter *models.TodoFilter) ([]*models.Todo, error)
+	FindTodos(ctx context.Context, userID uuid.UUID, filter *models.TodoFilter) ([]*models.Todo, error)
+	FindTodosInBatches(ctx context.Context, userID uuid.UUID, filter *models.TodoFilter, size int, fn func(todos []*models.Todo) error) error
+	GetSubtree(ctx context.Context, uuid uuid.UUID) ([]*models.Todo, error)
+	GetUploads(ctx context.Context, keys []string) ([]*models.Upload, error)
+	GetAttachmentKeys(ctx context.Context, todoIDs []uuid.UUID) ([]string, error)
+	LockPositions(ctx context.Context, userID uuid.UUID) error
+	GetLastPosition(ctx context.Context, userID uuid.UUID) (string, error)
+	GetAdjacentPosition(ctx context.Context, userID uuid.UUID, exclude uuid.UUID, position string, previous bool) (string, error)
+	MoveTodo(ctx context.Context, uuid uuid.UUID, parentID *uuid.UUID, position string) error
+	ArchiveTodos(ctx context.Context, ids []uuid.UUID, archivedAt *time.Time) error
+	DeleteTodo(ctx context.Context, uuid uuid.UUID, mode string) error
+	AddEvent(ctx context.Context, aggregateID uuid.UUID, event bus.Event) error
+	Transaction(ctx context.Context, fn func(repo TodoRepo) error) error
 }
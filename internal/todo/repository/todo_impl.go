@@ -2,13 +2,22 @@ package repository
 
 import (
 	"context"
+	"practice/env"
 	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/outbox"
 	"practice/pkg/pagination"
+	"practice/pkg/search"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// positionLockKey namespaces the advisory locks LockPositions takes per
+// user.
+const positionLockKey = 0x706f73
+
 type TodoRepoImpl struct {
 	db *gorm.DB
 }
@@ -20,11 +29,21 @@ func NewTodoRepo(db *gorm.DB) TodoRepo {
 }
 
 func (r *TodoRepoImpl) AddTodo(ctx context.Context, todo *models.Todo) error {
-	return r.db.WithContext(ctx).Model(&models.Todo{}).Create(todo).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Todo{}).Create(todo).Error; err != nil {
+			return err
+		}
+		return attachFiles(tx, todo.UserID, todo.Images)
+	})
 }
 
 func (r *TodoRepoImpl) UpdateTodo(ctx context.Context, todo *models.Todo) error {
-	return r.db.WithContext(ctx).Model(&models.Todo{}).Save(todo).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Todo{}).Save(todo).Error; err != nil {
+			return err
+		}
+		return attachFiles(tx, todo.UserID, todo.Images)
+	})
 }
 
 func (r *TodoRepoImpl) GetTodo(ctx context.Context, uuid uuid.UUID) (*models.Todo, error) {
@@ -33,29 +52,28 @@ func (r *TodoRepoImpl) GetTodo(ctx context.Context, uuid uuid.UUID) (*models.Tod
 	return todo, err
 }
 
-func (r *TodoRepoImpl) GetTodos(ctx context.Context, params *pagination.Pagination, userID *uuid.UUID, key string, value ...interface{}) ([]*models.Todo, error) {
+func (r *TodoRepoImpl) GetTodos(ctx context.Context, params *pagination.Pagination, userID *uuid.UUID, filter *models.TodoFilter) ([]*models.Todo, error) {
 	var todos []*models.Todo
 
-	query := r.db.WithContext(ctx)
-	if userID != nil {
-		query = query.Where("user_id = ?", userID)
-	}
-
-	if len(value) > 0 && value[0] != "" {
-		searchTerm := "%" + value[0].(string) + "%"
-		query = query.Where(`
-			title ILIKE ? OR
-			EXISTS (
-			SELECT 1 FROM unnest(todo) AS item 
-			WHERE item ILIKE ?
-		)`, searchTerm, searchTerm)
-	}
+	query := filterTodos(r.db.WithContext(ctx), userID, filter)
 
 	paginated, err := pagination.Paginate(&models.Todo{}, params, query)
 	if err != nil {
 		return nil, err
 	}
 
+	if filter != nil && filter.Search != "" {
+		vector, vectorArgs := searchVector(filter.Language)
+		tsquery, queryArgs := searchQuery(filter)
+
+		args := append(append(append([]interface{}{}, vectorArgs...), queryArgs...), searchLanguage(filter))
+		args = append(append(args, queryArgs...), headlineOptions)
+
+		paginated = paginated.Select(`todos.*,
+			ts_rank_cd(`+vector+`, `+tsquery+`) AS rank,
+			ts_headline(?::regconfig, todo_search_text(title, todo), `+tsquery+`, ?) AS headline`, args...)
+	}
+
 	if err := paginated.Preload("User").Find(&todos).Error; err != nil {
 		return nil, err
 	}
@@ -63,6 +81,257 @@ func (r *TodoRepoImpl) GetTodos(ctx context.Context, params *pagination.Paginati
 	return todos, nil
 }
 
-func (r *TodoRepoImpl) DeleteTodo(ctx context.Context, uuid uuid.UUID) error {
-	return r.db.WithContext(ctx).Model(&models.Todo{}).Where("id = ?", uuid).Delete(&models.Todo{}).Error
+// FindTodos returns every todo of the user matching the filter, without
+// pagination.
+func (r *TodoRepoImpl) FindTodos(ctx context.Context, userID uuid.UUID, filter *models.TodoFilter) ([]*models.Todo, error) {
+	var todos []*models.Todo
+
+	err := filterTodos(r.db.WithContext(ctx), &userID, filter).Order("created_at").Find(&todos).Error
+	return todos, err
+}
+
+// FindTodosInBatches calls fn with the user's todos matching the filter in
+// list order, size todos at a time, so large exports never load the whole
+// list into memory.
+func (r *TodoRepoImpl) FindTodosInBatches(ctx context.Context, userID uuid.UUID, filter *models.TodoFilter, size int, fn func(todos []*models.Todo) error) error {
+	query := filterTodos(r.db.WithContext(ctx), &userID, filter).Order(`position COLLATE "C", id`)
+
+	for offset := 0; ; offset += size {
+		var todos []*models.Todo
+		if err := query.Session(&gorm.Session{}).Offset(offset).Limit(size).Find(&todos).Error; err != nil {
+			return err
+		}
+
+		if len(todos) == 0 {
+			return nil
+		}
+
+		if err := fn(todos); err != nil {
+			return err
+		}
+
+		if len(todos) < size {
+			return nil
+		}
+	}
+}
+
+// AddEvent stores an event in the outbox. Called within Transaction, the
+// event is only relayed if the transaction commits.
+func (r *TodoRepoImpl) AddEvent(ctx context.Context, aggregateID uuid.UUID, event bus.Event) error {
+	return outbox.Store(r.db.WithContext(ctx), aggregateID.String(), event)
+}
+
+func (r *TodoRepoImpl) Transaction(ctx context.Context, fn func(repo TodoRepo) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&TodoRepoImpl{db: tx})
+	})
+}
+
+// filterTodos narrows query to the todos matching filter. A nil filter is
+// the empty one, so archived todos are still left out.
+func filterTodos(query *gorm.DB, userID *uuid.UUID, filter *models.TodoFilter) *gorm.DB {
+	if userID != nil {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	if filter.TopLevel {
+		query = query.Where("parent_id IS NULL")
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	switch filter.Archived {
+	case models.ArchivedAll:
+	case models.ArchivedOnly:
+		query = query.Where("archived_at IS NOT NULL")
+	default:
+		query = query.Where("archived_at IS NULL")
+	}
+
+	if filter.Search != "" {
+		vector, vectorArgs := searchVector(filter.Language)
+		tsquery, queryArgs := searchQuery(filter)
+		query = query.Where(vector+" @@ "+tsquery, append(vectorArgs, queryArgs...)...)
+	}
+
+	return query
+}
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+func searchLanguage(filter *models.TodoFilter) string {
+	if filter.Language == "" {
+		return env.SearchLanguage
+	}
+	return filter.Language
+}
+
+// searchVector uses the indexed search_vector column when the query
+// language matches the one it was generated with, and falls back to an
+// unindexed to_tsvector otherwise.
+func searchVector(lang string) (string, []interface{}) {
+	if lang == "" || lang == env.SearchLanguage {
+		return "search_vector", nil
+	}
+
+	return "to_tsvector(?::regconfig, todo_search_text(title, todo))", []interface{}{lang}
+}
+
+func searchQuery(filter *models.TodoFilter) (string, []interface{}) {
+	lang := searchLanguage(filter)
+
+	if filter.Prefix {
+		if prefix := search.PrefixQuery(filter.Search); prefix != "" {
+			return "to_tsquery(?::regconfig, ?)", []interface{}{lang, prefix}
+		}
+	}
+
+	return "websearch_to_tsquery(?::regconfig, ?)", []interface{}{lang, filter.Search}
+}
+
+// GetSubtree returns the todo with the given ID followed by all of its
+// descendants, in breadth-first order.
+func (r *TodoRepoImpl) GetSubtree(ctx context.Context, uuid uuid.UUID) ([]*models.Todo, error) {
+	var todos []*models.Todo
+
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE subtree AS (
+			SELECT todos.*, 0 AS depth FROM todos WHERE id = ?
+			UNION ALL
+			SELECT t.*, s.depth + 1 FROM todos t JOIN subtree s ON t.parent_id = s.id
+		)
+		SELECT * FROM subtree ORDER BY depth, position COLLATE "C", created_at`, uuid).Scan(&todos).Error
+
+	return todos, err
+}
+
+// GetUploads returns the uploads stored under the given keys, one for each
+// user holding the file. Keys with no upload recorded are skipped.
+func (r *TodoRepoImpl) GetUploads(ctx context.Context, keys []string) ([]*models.Upload, error) {
+	var uploads []*models.Upload
+	if len(keys) == 0 {
+		return uploads, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("key IN ?", keys).Find(&uploads).Error
+
+	return uploads, err
+}
+
+// GetAttachmentKeys returns the keys of the files attached to the todos.
+func (r *TodoRepoImpl) GetAttachmentKeys(ctx context.Context, todoIDs []uuid.UUID) ([]string, error) {
+	var keys []string
+	if len(todoIDs) == 0 {
+		return keys, nil
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.Attachment{}).
+		Where("todo_id IN ?", todoIDs).
+		Pluck("key", &keys).Error
+
+	return keys, err
+}
+
+// LockPositions keeps other transactions from taking positions in the
+// user's list until the current one ends, so concurrent adds cannot get the
+// same position. Called within Transaction.
+func (r *TodoRepoImpl) LockPositions(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", positionLockKey, userID.String()).Error
+}
+
+// GetLastPosition returns the highest position among the user's todos, or
+// an empty string when the user has none.
+func (r *TodoRepoImpl) GetLastPosition(ctx context.Context, userID uuid.UUID) (string, error) {
+	var positions []string
+	err := r.db.WithContext(ctx).Model(&models.Todo{}).
+		Where("user_id = ?", userID).
+		Order(`position COLLATE "C" DESC`).
+		Limit(1).
+		Pluck("position", &positions).Error
+	if err != nil || len(positions) == 0 {
+		return "", err
+	}
+
+	return positions[0], nil
+}
+
+// GetAdjacentPosition returns the position right before (previous) or right
+// after the given one in the user's list, ignoring the todo being moved. An
+// empty string means there is no such neighbour.
+func (r *TodoRepoImpl) GetAdjacentPosition(ctx context.Context, userID uuid.UUID, exclude uuid.UUID, position string, previous bool) (string, error) {
+	query := r.db.WithContext(ctx).Model(&models.Todo{}).Where("user_id = ? AND id <> ?", userID, exclude)
+	if previous {
+		query = query.Where(`position COLLATE "C" < ?`, position).Order(`position COLLATE "C" DESC`)
+	} else {
+		query = query.Where(`position COLLATE "C" > ?`, position).Order(`position COLLATE "C" ASC`)
+	}
+
+	var positions []string
+	if err := query.Limit(1).Pluck("position", &positions).Error; err != nil || len(positions) == 0 {
+		return "", err
+	}
+
+	return positions[0], nil
+}
+
+func (r *TodoRepoImpl) MoveTodo(ctx context.Context, uuid uuid.UUID, parentID *uuid.UUID, position string) error {
+	return r.db.WithContext(ctx).Model(&models.Todo{}).Where("id = ?", uuid).Updates(map[string]interface{}{
+		"parent_id": parentID,
+		"position":  position,
+	}).Error
+}
+
+// ArchiveTodos sets or, with a nil archivedAt, clears the archive time of
+// the given todos.
+func (r *TodoRepoImpl) ArchiveTodos(ctx context.Context, ids []uuid.UUID, archivedAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Todo{}).Where("id IN ?", ids).Update("archived_at", archivedAt).Error
+}
+
+// DeleteTodo removes a todo. With models.DeleteCascade its whole subtree is
+// removed as well, otherwise its children are moved up to its own parent.
+func (r *TodoRepoImpl) DeleteTodo(ctx context.Context, uuid uuid.UUID, mode string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var todo models.Todo
+		if err := tx.First(&todo, "id = ?", uuid).Error; err != nil {
+			return err
+		}
+
+		ids := []string{uuid.String()}
+		if mode == models.DeleteCascade {
+			ids = nil
+			if err := tx.Raw(`
+				WITH RECURSIVE subtree AS (
+					SELECT id FROM todos WHERE id = ?
+					UNION ALL
+					SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id
+				)
+				SELECT id FROM subtree`, uuid).Scan(&ids).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&models.Todo{}).Where("parent_id = ?", uuid).Update("parent_id", todo.ParentID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("todo_id IN ?", ids).Delete(&models.TodoActivity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("todo_id IN ?", ids).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("todo_id IN ?", ids).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ?", ids).Delete(&models.Todo{}).Error
+	})
 }
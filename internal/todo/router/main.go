@@ -2,30 +2,120 @@ package router
 
 import (
 	"practice/config"
+	"practice/env"
 	"practice/internal/todo/handler"
 	"practice/internal/todo/repository"
 	"practice/internal/todo/usecase"
+	"practice/models"
 	"practice/pkg/bus"
 	"practice/pkg/logger"
 	"practice/pkg/middleware"
+	"practice/pkg/outbox"
+	"practice/pkg/storage"
 	"practice/pkg/validator"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func Route(f fiber.Router, db *config.DB, logger *logger.Logger) {
+func Route(f fiber.Router, db *config.DB, logger *logger.Logger, event *bus.EventBus, relay *outbox.Relay, blob storage.Blob) {
 	validator := validator.NewCustomValidator()
-	event := bus.NewEventBus()
 
 	repo := repository.NewTodoRepo(db.Instance())
-	usecase := usecase.NewTodoUsecase(repo, validator, logger)
-	handler := handler.NewTodoHandler(usecase, logger, event)
+	fileRepo := repository.NewFileRepo(db.Instance())
+	todoUsecase := usecase.NewTodoUsecase(repo, fileRepo, blob, validator, logger)
+	todoHandler := handler.NewTodoHandler(todoUsecase, logger, event)
+
+	commentRepo := repository.NewCommentRepo(db.Instance())
+	commentUsecase := usecase.NewCommentUsecase(commentRepo, repo, validator, logger)
+	commentHandler := handler.NewCommentHandler(commentUsecase, logger, event)
+
+	activityRepo := repository.NewActivityRepo(db.Instance())
+	activityUsecase := usecase.NewActivityUsecase(activityRepo, repo, logger)
+	activityHandler := handler.NewActivityHandler(activityUsecase, logger)
+
+	templateRepo := repository.NewTemplateRepo(db.Instance())
+	templateUsecase := usecase.NewTemplateUsecase(templateRepo, fileRepo, todoUsecase, blob, validator, logger)
+	templateHandler := handler.NewTemplateHandler(templateUsecase, logger, event)
+
+	transferUsecase := usecase.NewTransferUsecase(repo, validator, logger)
+	transferHandler := handler.NewTransferHandler(transferUsecase, logger, event)
+
+	calendarRepo := repository.NewCalendarRepo(db.Instance())
+	calendarUsecase := usecase.NewCalendarUsecase(calendarRepo, repo, logger)
+	calendarHandler := handler.NewCalendarHandler(calendarUsecase, logger)
+
+	attachmentRepo := repository.NewAttachmentRepo(db.Instance())
+	attachmentUsecase := usecase.NewAttachmentUsecase(attachmentRepo, repo, fileRepo, blob, validator, logger)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentUsecase, logger)
+
+	statsRepo := repository.NewStatsRepo(db.Instance())
+	statsUsecase := usecase.NewStatsUsecase(statsRepo, validator, logger)
+	statsHandler := handler.NewStatsHandler(statsUsecase, logger)
+
+	relay.Register(models.TodoCreated{}, models.TodoChange{}, models.TodoCompleted{}, models.TodoReopened{})
+
+	event.Register(
+		bus.OnPattern("todo.*", todoHandler),
+		bus.On(activityHandler.TodoCreated),
+		bus.On(activityHandler.TodoUpdated),
+		bus.On(activityHandler.CommentCreated),
+	)
+
+	upload := middleware.Upload(blob, middleware.UploadLimits{
+		MaxFileSize:    int64(env.UploadMaxFileSize),
+		MaxRequestSize: int64(env.UploadMaxRequestSize),
+		MaxFiles:       int(env.MaxTodoImages),
+		AllowedTypes:   env.UploadAllowedTypes,
+	})
+	attach := middleware.UploadFiles(blob, "files", middleware.UploadLimits{
+		MaxFileSize:    int64(env.UploadMaxFileSize),
+		MaxRequestSize: int64(env.UploadMaxRequestSize),
+		MaxFiles:       int(env.MaxTodoAttachments),
+		AllowedTypes:   env.AttachmentTypes,
+	})
+
+	f.Get("/calendar/:token.ics", calendarHandler.GetFeed)
 
 	todo := f.Group("/todo", middleware.JWTAuth())
 
-	todo.Get("", handler.GetTodos)
-	todo.Get("/:id", handler.GetTodo)
-	todo.Post("", middleware.Upload(), handler.AddTodo)
-	todo.Put("/:id", middleware.Upload(), handler.UpdateTodo)
-	todo.Delete("/:id", handler.DeleteTodo)
+	todo.Post("/calendar/token", calendarHandler.CreateToken)
+	todo.Delete("/calendar/token", calendarHandler.RevokeToken)
+
+	todo.Get("/templates", templateHandler.GetTemplates)
+	todo.Post("/templates", upload, templateHandler.AddTemplate)
+	todo.Get("/templates/:templateId", templateHandler.GetTemplate)
+	todo.Delete("/templates/:templateId", templateHandler.DeleteTemplate)
+	todo.Post("/templates/:templateId/instantiate", templateHandler.InstantiateTemplate)
+
+	todo.Get("/stats", statsHandler.GetStats)
+
+	todo.Get("/export", transferHandler.ExportTodos)
+	todo.Post("/import", transferHandler.ImportTodos)
+
+	todo.Get("", todoHandler.GetTodos)
+	todo.Get("/:id", todoHandler.GetTodo)
+	todo.Post("", upload, todoHandler.AddTodo)
+	todo.Post("/bulk", todoHandler.BulkTodos)
+	todo.Put("/:id", upload, todoHandler.UpdateTodo)
+	todo.Delete("/:id", todoHandler.DeleteTodo)
+	todo.Get("/:id/tree", todoHandler.GetTodoTree)
+	todo.Post("/:id/move", todoHandler.MoveTodo)
+	todo.Post("/:id/archive", todoHandler.ArchiveTodo)
+	todo.Post("/:id/unarchive", todoHandler.UnarchiveTodo)
+	todo.Post("/:id/duplicate", todoHandler.DuplicateTodo)
+	todo.Post("/:id/template", templateHandler.AddTemplateFromTodo)
+
+	todo.Post("/:id/images", upload, todoHandler.AddImages)
+	todo.Put("/:id/images", todoHandler.ReorderImages)
+	todo.Delete("/:id/images/:imageId", todoHandler.RemoveImage)
+
+	todo.Get("/:id/attachments", attachmentHandler.GetAttachments)
+	todo.Post("/:id/attachments", attach, attachmentHandler.AddAttachments)
+	todo.Delete("/:id/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
+
+	todo.Get("/:id/comments", commentHandler.GetComments)
+	todo.Post("/:id/comments", commentHandler.AddComment)
+	todo.Put("/:id/comments/:commentId", commentHandler.UpdateComment)
+	todo.Delete("/:id/comments/:commentId", commentHandler.DeleteComment)
+	todo.Get("/:id/activity", activityHandler.GetActivities)
 }
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type ActivityUsecase interface {
+	RecordCreated(ctx context.Context, todo *models.Todo) error
+	RecordChange(ctx context.Context, change *models.TodoChange) error
+	RecordComment(ctx context.Context, comment *models.Comment) error
+	GetActivities(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, todoID uuid.UUID) ([]*models.TodoActivity, error)
+}
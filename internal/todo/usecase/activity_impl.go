@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type ActivityUsecaseImpl struct {
+	repo     repository.ActivityRepo
+	todoRepo repository.TodoRepo
+	logger   *logger.Logger
+}
+
+func NewActivityUsecase(repo repository.ActivityRepo, todoRepo repository.TodoRepo, logger *logger.Logger) ActivityUsecase {
+	return &ActivityUsecaseImpl{
+		repo:     repo,
+		todoRepo: todoRepo,
+		logger:   logger,
+	}
+}
+
+func (u *ActivityUsecaseImpl) RecordCreated(ctx context.Context, todo *models.Todo) error {
+	return u.repo.AddActivities(ctx, []*models.TodoActivity{{
+		Type:    models.ActivityTodoCreated,
+		Detail:  todo.Title,
+		TodoID:  todo.ID,
+		ActorID: todo.UserID,
+	}})
+}
+
+// RecordChange diffs the before and after state of an update and stores one
+// timeline entry per title change, checked/unchecked item, added image and
+// completion or reopening.
+func (u *ActivityUsecaseImpl) RecordChange(ctx context.Context, change *models.TodoChange) error {
+	if change.Before == nil || change.After == nil {
+		return nil
+	}
+
+	var activities []*models.TodoActivity
+	add := func(kind, detail string) {
+		activities = append(activities, &models.TodoActivity{
+			Type:    kind,
+			Detail:  detail,
+			TodoID:  change.After.ID,
+			ActorID: change.ActorID,
+		})
+	}
+
+	if change.Before.Title != change.After.Title {
+		add(models.ActivityTitleChanged, change.After.Title)
+	}
+	for _, item := range difference(change.After.Check, change.Before.Check) {
+		add(models.ActivityItemChecked, item)
+	}
+	for _, item := range difference(change.Before.Check, change.After.Check) {
+		add(models.ActivityItemUnchecked, item)
+	}
+	for _, image := range difference(change.After.Images, change.Before.Images) {
+		add(models.ActivityImageAdded, image)
+	}
+	wasDone := change.Before.Status == models.TodoStatusDone
+	isDone := change.After.Status == models.TodoStatusDone
+	if isDone && !wasDone {
+		add(models.ActivityTodoCompleted, "")
+	} else if wasDone && !isDone {
+		add(models.ActivityTodoReopened, "")
+	}
+
+	return u.repo.AddActivities(ctx, activities)
+}
+
+func (u *ActivityUsecaseImpl) RecordComment(ctx context.Context, comment *models.Comment) error {
+	return u.repo.AddActivities(ctx, []*models.TodoActivity{{
+		Type:      models.ActivityCommentCreated,
+		TodoID:    comment.TodoID,
+		ActorID:   comment.UserID,
+		CommentID: &comment.ID,
+	}})
+}
+
+func (u *ActivityUsecaseImpl) GetActivities(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, todoID uuid.UUID) ([]*models.TodoActivity, error) {
+	if _, err := ownTodo(ctx, u.todoRepo, userID, todoID); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	p := pagination.NewPagination(params)
+
+	return u.repo.GetActivities(ctx, p, todoID)
+}
+
+// difference returns the items of a that are not in b.
+func difference(a, b []string) []string {
+	known := make(map[string]bool, len(b))
+	for _, item := range b {
+		known[item] = true
+	}
+
+	var diff []string
+	for _, item := range a {
+		if !known[item] {
+			diff = append(diff, item)
+		}
+	}
+
+	return diff
+}
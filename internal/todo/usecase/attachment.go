@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"context"
+	"practice/models"
+
+	"github.com/google/uuid"
+)
+
+type AttachmentUsecase interface {
+	// AddAttachments attaches uploaded files to the user's todo.
+	AddAttachments(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, attachments []*models.Attachment) ([]*models.Attachment, error)
+	// AttachUploads attaches the user's completed resumable uploads to the
+	// todo. The uploads are used up.
+	AttachUploads(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, request *models.AttachmentRequest) ([]*models.Attachment, error)
+	GetAttachments(ctx context.Context, userID uuid.UUID, todoID uuid.UUID) ([]*models.Attachment, error)
+	// DeleteAttachment removes the attachment from the todo and deletes its
+	// file.
+	DeleteAttachment(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, uuid uuid.UUID) error
+}
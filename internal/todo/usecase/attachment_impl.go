@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"practice/env"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/storage"
+	"practice/pkg/validator"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrTooManyAttachments = errors.New("too many attachments")
+	ErrUploadNotFound     = errors.New("upload not found")
+)
+
+type AttachmentUsecaseImpl struct {
+	repo      repository.AttachmentRepo
+	todoRepo  repository.TodoRepo
+	files     repository.FileRepo
+	blob      storage.Blob
+	validator *validator.CustomValidator
+	logger    *logger.Logger
+}
+
+func NewAttachmentUsecase(repo repository.AttachmentRepo, todoRepo repository.TodoRepo, files repository.FileRepo, blob storage.Blob, validator *validator.CustomValidator, logger *logger.Logger) AttachmentUsecase {
+	return &AttachmentUsecaseImpl{
+		repo:      repo,
+		todoRepo:  todoRepo,
+		files:     files,
+		blob:      blob,
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+// AddAttachments deletes the files again when they cannot be attached, as
+// nothing else references them.
+func (u *AttachmentUsecaseImpl) AddAttachments(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, attachments []*models.Attachment) ([]*models.Attachment, error) {
+	if err := u.addAttachments(ctx, userID, todoID, attachments, nil); err != nil {
+		keys := make([]string, len(attachments))
+		for i, attachment := range attachments {
+			keys[i] = attachment.Key
+		}
+		releaseFiles(ctx, u.files, u.blob, u.logger, keys)
+		return nil, err
+	}
+
+	u.resolveURLs(ctx, attachments...)
+
+	return attachments, nil
+}
+
+// AttachUploads leaves the uploads alone when they cannot be attached, so
+// the request can be retried until they expire.
+func (u *AttachmentUsecaseImpl) AttachUploads(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, request *models.AttachmentRequest) ([]*models.Attachment, error) {
+	if err := u.validator.Validate(request); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	ids := slices.Compact(slices.SortedFunc(slices.Values(request.Uploads), func(a, b uuid.UUID) int {
+		return slices.Compare(a[:], b[:])
+	}))
+	uploads, err := u.repo.GetResumableUploads(ctx, userID, ids)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+	if len(uploads) != len(ids) {
+		return nil, ErrUploadNotFound
+	}
+
+	attachments := make([]*models.Attachment, len(uploads))
+	for i, upload := range uploads {
+		attachments[i] = &models.Attachment{
+			Filename:    upload.Filename,
+			Key:         upload.Key,
+			Size:        upload.Length,
+			ContentType: upload.ContentType,
+			Checksum:    upload.Checksum,
+		}
+	}
+
+	err = u.addAttachments(ctx, userID, todoID, attachments, ids)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.resolveURLs(ctx, attachments...)
+
+	return attachments, nil
+}
+
+// addAttachments attaches the files to the todo, using up the resumable
+// uploads they came from, if any.
+func (u *AttachmentUsecaseImpl) addAttachments(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, attachments []*models.Attachment, uploads []uuid.UUID) error {
+	if err := u.checkTodo(ctx, userID, todoID); err != nil {
+		return err
+	}
+
+	count, err := u.repo.CountAttachments(ctx, todoID)
+	if err != nil {
+		return err
+	}
+	if env.MaxTodoAttachments > 0 && uint64(count)+uint64(len(attachments)) > env.MaxTodoAttachments {
+		return ErrTooManyAttachments
+	}
+
+	for _, attachment := range attachments {
+		attachment.TodoID = todoID
+		attachment.UserID = userID
+	}
+
+	if len(uploads) > 0 {
+		err = u.repo.AttachUploads(ctx, attachments, uploads)
+	} else {
+		err = u.repo.AddAttachments(ctx, attachments)
+	}
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (u *AttachmentUsecaseImpl) GetAttachments(ctx context.Context, userID uuid.UUID, todoID uuid.UUID) ([]*models.Attachment, error) {
+	if err := u.checkTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := u.repo.GetAttachments(ctx, todoID)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	u.resolveURLs(ctx, attachments...)
+
+	return attachments, nil
+}
+
+func (u *AttachmentUsecaseImpl) DeleteAttachment(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, uuid uuid.UUID) error {
+	if err := u.checkTodo(ctx, userID, todoID); err != nil {
+		return err
+	}
+
+	attachment, err := u.repo.GetAttachment(ctx, uuid)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrAttachmentNotFound
+		}
+		return err
+	}
+	if attachment.TodoID != todoID {
+		return ErrAttachmentNotFound
+	}
+
+	if err := u.repo.DeleteAttachment(ctx, uuid); err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	releaseFiles(ctx, u.files, u.blob, u.logger, []string{attachment.Key})
+
+	return nil
+}
+
+// checkTodo makes sure the todo exists and belongs to the user.
+func (u *AttachmentUsecaseImpl) checkTodo(ctx context.Context, userID uuid.UUID, todoID uuid.UUID) error {
+	if _, err := ownTodo(ctx, u.todoRepo, userID, todoID); err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// resolveURLs fills in the URL of the attachments. Attachments whose URL
+// cannot be signed are left without one.
+func (u *AttachmentUsecaseImpl) resolveURLs(ctx context.Context, attachments ...*models.Attachment) {
+	for _, attachment := range attachments {
+		url, err := u.blob.SignedURL(ctx, attachment.Key, fileURLExpiry)
+		if err != nil {
+			u.logger.Warn("failed to sign attachment URL", "attachment", attachment.ID, "error", err)
+			continue
+		}
+		attachment.URL = url
+	}
+}
@@ -0,0 +1,14 @@
+package usecase
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+type CalendarUsecase interface {
+	CreateToken(ctx context.Context, userID uuid.UUID) (string, error)
+	RevokeToken(ctx context.Context, userID uuid.UUID) error
+	WriteFeed(ctx context.Context, token string, w io.Writer) error
+}
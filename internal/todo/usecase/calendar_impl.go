@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+type CalendarUsecaseImpl struct {
+	repo     repository.CalendarRepo
+	todoRepo repository.TodoRepo
+	logger   *logger.Logger
+}
+
+func NewCalendarUsecase(repo repository.CalendarRepo, todoRepo repository.TodoRepo, logger *logger.Logger) CalendarUsecase {
+	return &CalendarUsecaseImpl{
+		repo:     repo,
+		todoRepo: todoRepo,
+		logger:   logger,
+	}
+}
+
+// CreateToken issues a new secret feed token for the user, invalidating
+// the previous one. The token is only returned here; just its hash is kept.
+func (u *CalendarUsecaseImpl) CreateToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		u.logger.Debug(err.Error())
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	feed := &models.CalendarFeed{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+	}
+
+	if err := u.repo.SaveFeed(ctx, feed); err != nil {
+		u.logger.Debug(err.Error())
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (u *CalendarUsecaseImpl) RevokeToken(ctx context.Context, userID uuid.UUID) error {
+	return u.repo.DeleteFeed(ctx, userID)
+}
+
+// WriteFeed writes the todos of the token's owner as an iCalendar feed.
+func (u *CalendarUsecaseImpl) WriteFeed(ctx context.Context, token string, w io.Writer) error {
+	feed, err := u.repo.GetFeedByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		u.logger.Debug(err.Error())
+		if err == gorm.ErrRecordNotFound {
+			return ErrFeedNotFound
+		}
+		return err
+	}
+
+	encoder, err := newTodoEncoder(models.FormatICal, w)
+	if err != nil {
+		return err
+	}
+
+	if err := encoder.Begin(); err != nil {
+		return err
+	}
+
+	err = u.todoRepo.FindTodosInBatches(ctx, feed.UserID, nil, exportBatchSize, func(todos []*models.Todo) error {
+		for _, todo := range todos {
+			if err := encoder.Encode(todo); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	return encoder.End()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
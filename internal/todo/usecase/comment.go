@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type CommentUsecase interface {
+	AddComment(ctx context.Context, comment *models.CommentRequest) (*models.Comment, []models.CommentMention, error)
+	UpdateComment(ctx context.Context, uuid uuid.UUID, comment *models.CommentRequest) (*models.Comment, []models.CommentMention, error)
+	GetComments(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, todoID uuid.UUID) ([]*models.Comment, error)
+	DeleteComment(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error
+}
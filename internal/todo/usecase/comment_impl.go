@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/pagination"
+	"practice/pkg/validator"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrCommentNotFound = errors.New("comment not found")
+
+// mentionPattern matches "@" followed by the email of the mentioned user,
+// e.g. "ping @jane@example.com".
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+type CommentUsecaseImpl struct {
+	repo      repository.CommentRepo
+	todoRepo  repository.TodoRepo
+	validator *validator.CustomValidator
+	logger    *logger.Logger
+}
+
+func NewCommentUsecase(repo repository.CommentRepo, todoRepo repository.TodoRepo, validator *validator.CustomValidator, logger *logger.Logger) CommentUsecase {
+	return &CommentUsecaseImpl{
+		repo:      repo,
+		todoRepo:  todoRepo,
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+func (u *CommentUsecaseImpl) AddComment(ctx context.Context, comment *models.CommentRequest) (*models.Comment, []models.CommentMention, error) {
+	err := u.validator.Validate(comment)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, nil, err
+	}
+
+	if _, err := ownTodo(ctx, u.todoRepo, comment.UserID, comment.TodoID); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, nil, err
+	}
+
+	mentioned, err := u.resolveMentions(ctx, comment.Body, comment.UserID)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, nil, err
+	}
+
+	commentModel := &models.Comment{
+		Body:     comment.Body,
+		Mentions: mentioned,
+		TodoID:   comment.TodoID,
+		UserID:   comment.UserID,
+	}
+
+	if err := u.repo.AddComment(ctx, commentModel); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, nil, err
+	}
+
+	return commentModel, newMentions(commentModel, nil), nil
+}
+
+func (u *CommentUsecaseImpl) UpdateComment(ctx context.Context, uuid uuid.UUID, comment *models.CommentRequest) (*models.Comment, []models.CommentMention, error) {
+	err := u.validator.Validate(comment)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, nil, err
+	}
+
+	existing, err := u.getComment(ctx, uuid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if existing.UserID != comment.UserID {
+		u.logger.Debug("Forbidden when update comment")
+		return nil, nil, ErrForbidden
+	}
+
+	mentioned, err := u.resolveMentions(ctx, comment.Body, comment.UserID)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, nil, err
+	}
+
+	previous := existing.Mentions
+	existing.Body = comment.Body
+	existing.Mentions = mentioned
+
+	if err := u.repo.UpdateComment(ctx, existing); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, nil, err
+	}
+
+	return existing, newMentions(existing, previous), nil
+}
+
+func (u *CommentUsecaseImpl) GetComments(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, todoID uuid.UUID) ([]*models.Comment, error) {
+	if _, err := ownTodo(ctx, u.todoRepo, userID, todoID); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	p := pagination.NewPagination(params)
+
+	return u.repo.GetComments(ctx, p, todoID)
+}
+
+func (u *CommentUsecaseImpl) DeleteComment(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error {
+	existing, err := u.getComment(ctx, uuid)
+	if err != nil {
+		return err
+	}
+
+	if existing.UserID != userID {
+		u.logger.Debug("Forbidden when delete comment")
+		return ErrForbidden
+	}
+
+	return u.repo.DeleteComment(ctx, uuid)
+}
+
+func (u *CommentUsecaseImpl) getComment(ctx context.Context, uuid uuid.UUID) (*models.Comment, error) {
+	existing, err := u.repo.GetComment(ctx, uuid)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// resolveMentions looks up the users mentioned in body and returns their IDs.
+// Unknown emails and self-mentions are ignored.
+func (u *CommentUsecaseImpl) resolveMentions(ctx context.Context, body string, authorID uuid.UUID) ([]string, error) {
+	var emails []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(match[1])
+		if !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+
+	users, err := u.repo.GetUsersByEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	mentioned := []string{}
+	for _, user := range users {
+		if user.ID != authorID {
+			mentioned = append(mentioned, user.ID.String())
+		}
+	}
+
+	return mentioned, nil
+}
+
+// newMentions returns a mention for every user in comment.Mentions that is
+// not already listed in previous.
+func newMentions(comment *models.Comment, previous []string) []models.CommentMention {
+	known := map[string]bool{}
+	for _, id := range previous {
+		known[id] = true
+	}
+
+	var mentions []models.CommentMention
+	for _, id := range comment.Mentions {
+		uid, err := uuid.Parse(id)
+		if err != nil || known[id] {
+			continue
+		}
+
+		mentions = append(mentions, models.CommentMention{
+			CommentID: comment.ID,
+			TodoID:    comment.TodoID,
+			AuthorID:  comment.UserID,
+			UserID:    uid,
+		})
+	}
+
+	return mentions
+}
@@ -0,0 +1,472 @@
+package usecase
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"practice/models"
+	"practice/pkg/ical"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidFormat = errors.New("unsupported format")
+	ErrInvalidImport = errors.New("invalid import file")
+)
+
+// todoEncoder writes todos one at a time so exports can be streamed.
+type todoEncoder interface {
+	Begin() error
+	Encode(todo *models.Todo) error
+	End() error
+}
+
+func newTodoEncoder(format string, w io.Writer) (todoEncoder, error) {
+	switch format {
+	case models.FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	case models.FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case models.FormatMarkdown:
+		return &markdownEncoder{w: w}, nil
+	case models.FormatICal:
+		return &icalEncoder{w: ical.NewWriter(w)}, nil
+	default:
+		return nil, ErrInvalidFormat
+	}
+}
+
+func toExport(todo *models.Todo) *models.TodoExport {
+	return &models.TodoExport{
+		ID:          todo.ID,
+		Title:       todo.Title,
+		Todo:        nonNil(todo.Todo),
+		Check:       nonNil(todo.Check),
+		Images:      nonNil(todo.Images),
+		DueAt:       todo.DueAt,
+		Status:      todo.Status,
+		CompletedAt: todo.CompletedAt,
+		ArchivedAt:  todo.ArchivedAt,
+		ParentID:    todo.ParentID,
+		CreatedAt:   todo.CreatedAt,
+		UpdatedAt:   todo.UpdatedAt,
+	}
+}
+
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonEncoder) Encode(todo *models.Todo) error {
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	data, err := json.Marshal(toExport(todo))
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonEncoder) End() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// csvHeader lists the exported columns. Checklist items are stored in a
+// single cell, one item per line.
+var csvHeader = []string{"id", "title", "todo", "check", "images", "due_at", "parent_id", "created_at", "updated_at"}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvEncoder) Encode(todo *models.Todo) error {
+	dueAt, parentID := "", ""
+	if todo.DueAt != nil {
+		dueAt = todo.DueAt.Format(time.RFC3339)
+	}
+	if todo.ParentID != nil {
+		parentID = todo.ParentID.String()
+	}
+
+	return e.w.Write([]string{
+		todo.ID.String(),
+		todo.Title,
+		strings.Join(todo.Todo, "\n"),
+		strings.Join(todo.Check, "\n"),
+		strings.Join(todo.Images, "\n"),
+		dueAt,
+		parentID,
+		todo.CreatedAt.Format(time.RFC3339),
+		todo.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type markdownEncoder struct {
+	w io.Writer
+}
+
+func (e *markdownEncoder) Begin() error {
+	return nil
+}
+
+func (e *markdownEncoder) Encode(todo *models.Todo) error {
+	checked := make(map[string]bool, len(todo.Check))
+	for _, item := range todo.Check {
+		checked[item] = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", singleLine(todo.Title))
+	for _, item := range todo.Todo {
+		mark := " "
+		if checked[item] {
+			mark = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s\n", mark, singleLine(item))
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *markdownEncoder) End() error {
+	return nil
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// importRow is one todo read from an import file. Row counts todos from 1;
+// Line points into the source file when the format has meaningful lines.
+// Err reports a value that could not be read into Request.
+type importRow struct {
+	Row     int
+	Line    int
+	Request *models.TodoRequest
+	Err     error
+}
+
+func decodeTodos(format string, r io.Reader) ([]importRow, error) {
+	switch format {
+	case models.FormatJSON:
+		return decodeJSON(r)
+	case models.FormatCSV:
+		return decodeCSV(r)
+	case models.FormatMarkdown:
+		return decodeMarkdown(r)
+	case models.FormatICal:
+		return decodeICal(r)
+	default:
+		return nil, ErrInvalidFormat
+	}
+}
+
+func decodeJSON(r io.Reader) ([]importRow, error) {
+	var requests []*models.TodoRequest
+	if err := json.NewDecoder(r).Decode(&requests); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	rows := make([]importRow, len(requests))
+	for i, request := range requests {
+		if request == nil {
+			request = &models.TodoRequest{}
+		}
+		rows[i] = importRow{Row: i + 1, Request: request}
+	}
+
+	return rows, nil
+}
+
+func decodeCSV(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: missing title column", ErrInvalidImport)
+	}
+
+	cell := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{
+			Row:  len(rows) + 1,
+			Line: line,
+			Request: &models.TodoRequest{
+				Title: strings.TrimSpace(cell(record, "title")),
+				Todo:  splitLines(cell(record, "todo")),
+				Check: splitLines(cell(record, "check")),
+			},
+		}
+
+		if due := strings.TrimSpace(cell(record, "due_at")); due != "" {
+			dueAt, err := time.Parse(time.RFC3339, due)
+			if err != nil {
+				row.Err = errors.New("due_at must be an RFC 3339 timestamp")
+			}
+			row.Request.DueAt = &dueAt
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func splitLines(cell string) []string {
+	items := []string{}
+	for _, line := range strings.Split(cell, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	markdownItem    = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*?)\s*$`)
+)
+
+// decodeMarkdown reads checklists where every heading starts a todo and
+// "- [ ]" / "- [x]" lines below it become its unchecked and checked items.
+// Any other line is ignored.
+func decodeMarkdown(r io.Reader) ([]importRow, error) {
+	var rows []importRow
+	var orphans []int
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		if match := markdownHeading.FindStringSubmatch(text); match != nil {
+			rows = append(rows, importRow{
+				Row:     len(rows) + 1,
+				Line:    line,
+				Request: &models.TodoRequest{Title: match[1], Todo: []string{}, Check: []string{}},
+			})
+			continue
+		}
+
+		match := markdownItem.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		if len(rows) == 0 {
+			orphans = append(orphans, line)
+			continue
+		}
+
+		request := rows[len(rows)-1].Request
+		request.Todo = append(request.Todo, match[2])
+		if match[1] != " " {
+			request.Check = append(request.Check, match[2])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	if len(orphans) > 0 {
+		return nil, fmt.Errorf("%w: checklist item on line %d has no heading", ErrInvalidImport, orphans[0])
+	}
+
+	return rows, nil
+}
+
+type icalEncoder struct {
+	w *ical.Writer
+}
+
+func (e *icalEncoder) Begin() error {
+	e.w.Begin("VCALENDAR")
+	e.w.Property("VERSION", "2.0")
+	e.w.Property("PRODID", ical.ProductIdentifier)
+	e.w.Property("CALSCALE", "GREGORIAN")
+	e.w.Text("X-WR-CALNAME", "Todos")
+	return e.w.Err()
+}
+
+// Encode writes the todo as a VTODO. Its status is derived from how many
+// checklist items are checked.
+func (e *icalEncoder) Encode(todo *models.Todo) error {
+	status, percent := checklistStatus(todo)
+
+	e.w.Begin("VTODO")
+	e.w.Property("UID", icalUID(todo.ID.String()))
+	e.w.Time("DTSTAMP", todo.UpdatedAt)
+	e.w.Time("CREATED", todo.CreatedAt)
+	e.w.Time("LAST-MODIFIED", todo.UpdatedAt)
+	e.w.Text("SUMMARY", todo.Title)
+	e.w.Text("DESCRIPTION", checklistText(todo))
+	e.w.Property("STATUS", status)
+	e.w.Property("PERCENT-COMPLETE", strconv.Itoa(percent))
+	if todo.DueAt != nil {
+		e.w.Time("DUE", *todo.DueAt)
+	}
+	if todo.CompletedAt != nil {
+		e.w.Time("COMPLETED", *todo.CompletedAt)
+	}
+	if todo.ParentID != nil {
+		e.w.Property("RELATED-TO", icalUID(todo.ParentID.String()))
+	}
+	e.w.End("VTODO")
+
+	return e.w.Err()
+}
+
+func (e *icalEncoder) End() error {
+	e.w.End("VCALENDAR")
+	return e.w.Err()
+}
+
+func icalUID(id string) string {
+	return id + "@practice"
+}
+
+func checklistStatus(todo *models.Todo) (string, int) {
+	items, done := checklistCount(todo)
+
+	switch checklistState(todo) {
+	case models.TodoStatusDone:
+		return "COMPLETED", 100
+	case models.TodoStatusInProgress:
+		return "IN-PROCESS", done * 100 / items
+	default:
+		return "NEEDS-ACTION", 0
+	}
+}
+
+func checklistText(todo *models.Todo) string {
+	checked := make(map[string]bool, len(todo.Check))
+	for _, item := range todo.Check {
+		checked[item] = true
+	}
+
+	lines := make([]string, len(todo.Todo))
+	for i, item := range todo.Todo {
+		mark := " "
+		if checked[item] {
+			mark = "x"
+		}
+		lines[i] = "[" + mark + "] " + singleLine(item)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var icalItem = regexp.MustCompile(`^\s*(?:[-*+]\s+)?\[([ xX])\]\s+(.*?)\s*$`)
+
+// decodeICal creates one row per VTODO or VEVENT. Checklist lines in the
+// DESCRIPTION ("[ ] item", "- [x] item") become items; without them the
+// summary is the only item, checked when the entry is COMPLETED.
+func decodeICal(r io.Reader) ([]importRow, error) {
+	calendar, err := ical.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	var rows []importRow
+	for _, component := range calendar.Find("VTODO", "VEVENT") {
+		row := importRow{
+			Row: len(rows) + 1,
+			Request: &models.TodoRequest{
+				Title: strings.TrimSpace(component.Text("SUMMARY")),
+				Todo:  []string{},
+				Check: []string{},
+			},
+		}
+		request := row.Request
+
+		for _, line := range strings.Split(component.Text("DESCRIPTION"), "\n") {
+			if match := icalItem.FindStringSubmatch(line); match != nil {
+				request.Todo = append(request.Todo, match[2])
+				if match[1] != " " {
+					request.Check = append(request.Check, match[2])
+				}
+			}
+		}
+
+		if len(request.Todo) == 0 && request.Title != "" {
+			request.Todo = []string{request.Title}
+			if strings.EqualFold(component.Text("STATUS"), "COMPLETED") {
+				request.Check = []string{request.Title}
+			}
+		}
+
+		due := "DUE"
+		if component.Name == "VEVENT" {
+			due = "DTSTART"
+		}
+		dueAt, err := component.Time(due, time.Local)
+		if err != nil {
+			row.Err = err
+		}
+		request.DueAt = dueAt
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
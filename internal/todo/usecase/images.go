@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"path"
+	"practice/env"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/storage"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// shareImages lets a new todo or template use the uploaded images too.
+// Files are stored by content, so the images are shared rather than
+// copied; userID becomes a holder of those it did not upload, which count
+// against its storage quota.
+func shareImages(ctx context.Context, blob storage.Blob, userID uuid.UUID, names []string) (pq.StringArray, error) {
+	if linker, ok := blob.(storage.Linker); ok {
+		ctx = storage.WithOwner(ctx, userID.String())
+		for _, name := range names {
+			if err := linker.Link(ctx, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return append(pq.StringArray{}, names...), nil
+}
+
+// releaseFiles deletes the images or attachments, and the variants of
+// images, that nothing references anymore. Files are shared by content,
+// so other todos or users may still use them.
+// Failures are only logged; the upload sweeper deletes what is left behind.
+func releaseFiles(ctx context.Context, files repository.FileRepo, blob storage.Blob, logger *logger.Logger, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	unused, err := files.UnusedFiles(ctx, keys)
+	if err != nil {
+		logger.Warn("failed to release files", "error", err)
+		return
+	}
+
+	for _, key := range unused {
+		if err := blob.Delete(ctx, key); err != nil {
+			logger.Warn("failed to delete file", "key", key, "error", err)
+		}
+	}
+}
+
+// droppedImages returns the images of before that after no longer has.
+func droppedImages(before, after []string) []string {
+	var dropped []string
+	for _, image := range before {
+		if !slices.Contains(after, image) {
+			dropped = append(dropped, image)
+		}
+	}
+
+	return dropped
+}
+
+// checkImageCount enforces MAX_TODO_IMAGES.
+func checkImageCount(names []string) error {
+	if env.MaxTodoImages > 0 && uint64(len(names)) > env.MaxTodoImages {
+		return ErrTooManyImages
+	}
+
+	return nil
+}
+
+// fileURLExpiry is how long the file URLs in responses stay valid.
+const fileURLExpiry = time.Hour
+
+// imageURLs signs a URL for each image.
+func imageURLs(ctx context.Context, blob storage.Blob, names []string) ([]string, error) {
+	urls := make([]string, len(names))
+	for i, name := range names {
+		url, err := blob.SignedURL(ctx, name, fileURLExpiry)
+		if err != nil {
+			return nil, err
+		}
+		urls[i] = url
+	}
+
+	return urls, nil
+}
+
+// imageVariants signs a URL for each variant of an image, keyed by variant
+// name.
+func imageVariants(ctx context.Context, blob storage.Blob, upload *models.Upload) (map[string]string, error) {
+	variants := make(map[string]string, len(upload.Variants))
+	for _, key := range upload.Variants {
+		url, err := blob.SignedURL(ctx, key, fileURLExpiry)
+		if err != nil {
+			return nil, err
+		}
+		variants[strings.TrimSuffix(path.Base(key), path.Ext(key))] = url
+	}
+
+	return variants, nil
+}
+
+// resolveImages fills in ImageURLs and ImageVariants of the todos and their
+// children. Todos whose images cannot be signed are left without URLs, and
+// images that are not processed yet have no variants.
+func (u *TodoUsecaseImpl) resolveImages(ctx context.Context, todos ...*models.Todo) {
+	var keys []string
+	var collect func(todos []*models.Todo)
+	collect = func(todos []*models.Todo) {
+		for _, todo := range todos {
+			keys = append(keys, todo.Images...)
+			collect(todo.Children)
+		}
+	}
+	collect(todos)
+
+	uploads := make(map[string]*models.Upload)
+	if len(keys) > 0 {
+		found, err := u.repo.GetUploads(ctx, keys)
+		if err != nil {
+			u.logger.Warn("failed to load image variants", "error", err)
+		}
+		// a file has an upload per holder; the processed ones list variants
+		for _, upload := range found {
+			if existing, ok := uploads[upload.Key]; !ok || existing.ProcessedAt == nil {
+				uploads[upload.Key] = upload
+			}
+		}
+	}
+
+	u.signImages(ctx, uploads, todos)
+}
+
+func (u *TodoUsecaseImpl) signImages(ctx context.Context, uploads map[string]*models.Upload, todos []*models.Todo) {
+	for _, todo := range todos {
+		urls, err := imageURLs(ctx, u.blob, todo.Images)
+		if err != nil {
+			u.logger.Warn("failed to sign image URLs", "todo", todo.ID, "error", err)
+		}
+		todo.ImageURLs = urls
+
+		if len(todo.Images) > 0 {
+			todo.ImageVariants = make([]map[string]string, len(todo.Images))
+			for i, name := range todo.Images {
+				upload, ok := uploads[name]
+				if !ok || len(upload.Variants) == 0 {
+					continue
+				}
+				variants, err := imageVariants(ctx, u.blob, upload)
+				if err != nil {
+					u.logger.Warn("failed to sign image variant URLs", "todo", todo.ID, "error", err)
+					continue
+				}
+				todo.ImageVariants[i] = variants
+			}
+		}
+
+		u.signImages(ctx, uploads, todo.Children)
+	}
+}
+
+// resolveImages fills in ImageURLs of the templates.
+func (u *TemplateUsecaseImpl) resolveImages(ctx context.Context, templates ...*models.TodoTemplate) {
+	for _, template := range templates {
+		urls, err := imageURLs(ctx, u.blob, template.Images)
+		if err != nil {
+			u.logger.Warn("failed to sign image URLs", "template", template.ID, "error", err)
+		}
+		template.ImageURLs = urls
+	}
+}
+
+// AddImages deletes the images again when they cannot be added, like
+// AddTodo.
+func (u *TodoUsecaseImpl) AddImages(ctx context.Context, userID uuid.UUID, id uuid.UUID, images []string) (*models.Todo, error) {
+	todo, err := u.changeImages(ctx, userID, id, func(existing pq.StringArray) (pq.StringArray, error) {
+		return append(append(pq.StringArray{}, existing...), images...), nil
+	})
+	if err != nil {
+		releaseFiles(ctx, u.files, u.blob, u.logger, images)
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+func (u *TodoUsecaseImpl) RemoveImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, image string) (*models.Todo, error) {
+	return u.changeImages(ctx, userID, id, func(existing pq.StringArray) (pq.StringArray, error) {
+		for i, key := range existing {
+			if key == image || strings.TrimSuffix(key, path.Ext(key)) == image {
+				return slices.Delete(append(pq.StringArray{}, existing...), i, i+1), nil
+			}
+		}
+		return nil, ErrImageNotFound
+	})
+}
+
+func (u *TodoUsecaseImpl) ReorderImages(ctx context.Context, userID uuid.UUID, id uuid.UUID, request *models.TodoImagesRequest) (*models.Todo, error) {
+	if err := u.validator.Validate(request); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	return u.changeImages(ctx, userID, id, func(existing pq.StringArray) (pq.StringArray, error) {
+		if len(request.Images) != len(existing) {
+			return nil, ErrInvalidImages
+		}
+
+		seen := make(map[string]bool, len(existing))
+		for _, image := range request.Images {
+			if seen[image] || !slices.Contains(existing, image) {
+				return nil, ErrInvalidImages
+			}
+			seen[image] = true
+		}
+
+		return pq.StringArray(request.Images), nil
+	})
+}
+
+// changeImages replaces the images of the user's todo with those returned
+// by change and saves the todo together with its todo.updated event.
+// Images the todo loses are deleted.
+func (u *TodoUsecaseImpl) changeImages(ctx context.Context, userID uuid.UUID, id uuid.UUID, change func(existing pq.StringArray) (pq.StringArray, error)) (*models.Todo, error) {
+	var todo *models.Todo
+	var dropped []string
+	err := u.repo.Transaction(ctx, func(repo repository.TodoRepo) error {
+		existing, err := repo.GetTodo(ctx, id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if existing.UserID != userID {
+			return ErrForbidden
+		}
+
+		images, err := change(existing.Images)
+		if err != nil {
+			return err
+		}
+		if err := checkImageCount(images); err != nil {
+			return err
+		}
+
+		updated := *existing
+		updated.Images = images
+		if err := repo.UpdateTodo(ctx, &updated); err != nil {
+			return err
+		}
+
+		err = repo.AddEvent(ctx, id, bus.NewEvent(ctx, models.TodoChange{
+			ActorID: userID,
+			Before:  existing,
+			After:   &updated,
+		}))
+		if err != nil {
+			return err
+		}
+
+		todo = &updated
+		dropped = droppedImages(existing.Images, images)
+		return nil
+	})
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	releaseFiles(ctx, u.files, u.blob, u.logger, dropped)
+	u.resolveImages(ctx, todo)
+
+	return todo, nil
+}
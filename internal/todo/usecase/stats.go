@@ -0,0 +1,10 @@
+package usecase
+
+import (
+	"context"
+	"practice/models"
+)
+
+type StatsUsecase interface {
+	GetStats(ctx context.Context, request *models.TodoStatsRequest) (*models.TodoStats, error)
+}
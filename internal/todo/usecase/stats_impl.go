@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"practice/env"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/validator"
+	"sync"
+	"time"
+)
+
+var ErrInvalidRange = errors.New("invalid date range")
+
+const (
+	dateLayout = "2006-01-02"
+
+	// defaultStatsDays is the length of the range when from is omitted.
+	defaultStatsDays = 30
+	// maxStatsDays keeps the history to a bounded number of buckets.
+	maxStatsDays = 366 * 2
+)
+
+type StatsUsecaseImpl struct {
+	repo      repository.StatsRepo
+	validator *validator.CustomValidator
+	logger    *logger.Logger
+
+	ttl   time.Duration
+	mu    sync.Mutex
+	cache map[string]statsEntry
+}
+
+type statsEntry struct {
+	stats   *models.TodoStats
+	expires time.Time
+}
+
+// NewStatsUsecase creates a stats usecase that caches results for
+// STATS_CACHE_TTL seconds, or not at all when it is 0.
+func NewStatsUsecase(repo repository.StatsRepo, validator *validator.CustomValidator, logger *logger.Logger) StatsUsecase {
+	return &StatsUsecaseImpl{
+		repo:      repo,
+		validator: validator,
+		logger:    logger,
+		ttl:       time.Duration(env.StatsCacheTTL) * time.Second,
+		cache:     make(map[string]statsEntry),
+	}
+}
+
+// GetStats returns the user's todo counts and completion history. The
+// history covers whole days or weeks (starting on Monday) from From to To,
+// defaulting to the last 30 days, with empty periods included.
+func (u *StatsUsecaseImpl) GetStats(ctx context.Context, request *models.TodoStatsRequest) (*models.TodoStats, error) {
+	err := u.validator.Validate(request)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	interval := request.Interval
+	if interval == "" {
+		interval = models.StatsIntervalDay
+	}
+
+	from, to, err := statsRange(request, interval)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s", request.UserID, interval, from.Format(dateLayout), to.Format(dateLayout))
+	if stats := u.cached(key); stats != nil {
+		return stats, nil
+	}
+
+	stats, err := u.repo.GetTodoStats(ctx, request.UserID, from, to)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	completions, err := u.repo.GetCompletions(ctx, request.UserID, interval, from, to)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	stats.From = from
+	stats.To = to.AddDate(0, 0, -1)
+	stats.Interval = interval
+	stats.Completed = fillBuckets(completions, interval, from, to)
+
+	u.store(key, stats)
+
+	return stats, nil
+}
+
+// statsRange resolves the requested dates to the half-open range [from, to)
+// aligned to the interval.
+func statsRange(request *models.TodoStatsRequest, interval string) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if request.To != "" {
+		parsed, err := time.Parse(dateLayout, request.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidRange
+		}
+		to = parsed
+	}
+	to = to.AddDate(0, 0, 1)
+
+	from := to.AddDate(0, 0, -defaultStatsDays)
+	if request.From != "" {
+		parsed, err := time.Parse(dateLayout, request.From)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidRange
+		}
+		from = parsed
+	}
+
+	if interval == models.StatsIntervalWeek {
+		from = startOfWeek(from)
+	}
+
+	if !from.Before(to) || to.Sub(from) > maxStatsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+
+	return from, to, nil
+}
+
+// startOfWeek returns the Monday of the week of t, as date_trunc('week')
+// does.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// fillBuckets returns one bucket per period in [from, to), taking the
+// counts from completions where there are any.
+func fillBuckets(completions []models.TodoStatsBucket, interval string, from time.Time, to time.Time) []models.TodoStatsBucket {
+	step := 1
+	if interval == models.StatsIntervalWeek {
+		step = 7
+	}
+
+	counts := make(map[string]models.TodoStatsBucket, len(completions))
+	for _, bucket := range completions {
+		counts[bucket.Start.Format(dateLayout)] = bucket
+	}
+
+	buckets := []models.TodoStatsBucket{}
+	for start := from; start.Before(to); start = start.AddDate(0, 0, step) {
+		bucket := counts[start.Format(dateLayout)]
+		bucket.Start = start
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
+
+func (u *StatsUsecaseImpl) cached(key string) *models.TodoStats {
+	if u.ttl <= 0 {
+		return nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	entry, ok := u.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+
+	return entry.stats
+}
+
+func (u *StatsUsecaseImpl) store(key string, stats *models.TodoStats) {
+	if u.ttl <= 0 {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range u.cache {
+		if now.After(entry.expires) {
+			delete(u.cache, k)
+		}
+	}
+
+	u.cache[key] = statsEntry{stats: stats, expires: now.Add(u.ttl)}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"practice/models"
+	"time"
+)
+
+// checklistCount returns the number of checklist items of the todo and how
+// many of them are checked.
+func checklistCount(todo *models.Todo) (int, int) {
+	checked := make(map[string]bool, len(todo.Check))
+	for _, item := range todo.Check {
+		checked[item] = true
+	}
+
+	done := 0
+	for _, item := range todo.Todo {
+		if checked[item] {
+			done++
+		}
+	}
+
+	return len(todo.Todo), done
+}
+
+func checklistState(todo *models.Todo) string {
+	items, done := checklistCount(todo)
+
+	switch {
+	case items > 0 && done == items:
+		return models.TodoStatusDone
+	case done > 0:
+		return models.TodoStatusInProgress
+	default:
+		return models.TodoStatusOpen
+	}
+}
+
+// applyStatus derives the status of todo from its checklist and keeps
+// CompletedAt in step with it. previous is the stored state, or nil for a
+// new todo. It returns the transition into or out of done, if any.
+func applyStatus(todo *models.Todo, previous *models.Todo) string {
+	todo.Status = checklistState(todo)
+
+	if todo.Status != models.TodoStatusDone {
+		todo.CompletedAt = nil
+		if previous != nil && previous.Status == models.TodoStatusDone {
+			return models.TransitionReopened
+		}
+		return ""
+	}
+
+	if previous != nil && previous.Status == models.TodoStatusDone && previous.CompletedAt != nil {
+		todo.CompletedAt = previous.CompletedAt
+		return ""
+	}
+
+	now := time.Now()
+	todo.CompletedAt = &now
+	if previous != nil && previous.Status != models.TodoStatusDone {
+		return models.TransitionCompleted
+	}
+
+	return ""
+}
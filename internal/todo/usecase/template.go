@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type TemplateUsecase interface {
+	AddTemplate(ctx context.Context, template *models.TodoTemplateRequest) (*models.TodoTemplate, error)
+	AddTemplateFromTodo(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, request *models.TemplateFromTodoRequest) (*models.TodoTemplate, error)
+	GetTemplate(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.TodoTemplate, error)
+	GetTemplates(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID) ([]*models.TodoTemplate, error)
+	DeleteTemplate(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error
+	InstantiateTemplate(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, request *models.TemplateInstantiateRequest) (*models.Todo, error)
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/pagination"
+	"practice/pkg/storage"
+	"practice/pkg/validator"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+type TemplateUsecaseImpl struct {
+	repo        repository.TemplateRepo
+	files       repository.FileRepo
+	todoUsecase TodoUsecase
+	blob        storage.Blob
+	validator   *validator.CustomValidator
+	logger      *logger.Logger
+}
+
+func NewTemplateUsecase(repo repository.TemplateRepo, files repository.FileRepo, todoUsecase TodoUsecase, blob storage.Blob, validator *validator.CustomValidator, logger *logger.Logger) TemplateUsecase {
+	return &TemplateUsecaseImpl{
+		repo:        repo,
+		files:       files,
+		todoUsecase: todoUsecase,
+		blob:        blob,
+		validator:   validator,
+		logger:      logger,
+	}
+}
+
+// AddTemplate stores a new template. Its images are fresh uploads, deleted
+// again when the template cannot be added.
+func (u *TemplateUsecaseImpl) AddTemplate(ctx context.Context, template *models.TodoTemplateRequest) (*models.TodoTemplate, error) {
+	err := u.validator.Validate(template)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		releaseFiles(ctx, u.files, u.blob, u.logger, template.Images)
+		return nil, err
+	}
+
+	templateModel := &models.TodoTemplate{
+		Name:   template.Name,
+		Title:  template.Title,
+		Todo:   template.Todo,
+		Images: template.Images,
+		UserID: template.UserID,
+	}
+
+	if err := u.repo.AddTemplate(ctx, templateModel); err != nil {
+		u.logger.Debug(err.Error())
+		releaseFiles(ctx, u.files, u.blob, u.logger, template.Images)
+		return nil, err
+	}
+
+	u.resolveImages(ctx, templateModel)
+
+	return templateModel, nil
+}
+
+// AddTemplateFromTodo saves the title, items and images of an existing todo
+// as a template. The images are copied so the template outlives the todo.
+func (u *TemplateUsecaseImpl) AddTemplateFromTodo(ctx context.Context, userID uuid.UUID, todoID uuid.UUID, request *models.TemplateFromTodoRequest) (*models.TodoTemplate, error) {
+	err := u.validator.Validate(request)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	todo, err := u.todoUsecase.GetTodo(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := shareImages(ctx, u.blob, userID, todo.Images)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	templateModel := &models.TodoTemplate{
+		Name:   request.Name,
+		Title:  todo.Title,
+		Todo:   todo.Todo,
+		Images: images,
+		UserID: userID,
+	}
+
+	if err := u.repo.AddTemplate(ctx, templateModel); err != nil {
+		u.logger.Debug(err.Error())
+		releaseFiles(ctx, u.files, u.blob, u.logger, images)
+		return nil, err
+	}
+
+	u.resolveImages(ctx, templateModel)
+
+	return templateModel, nil
+}
+
+func (u *TemplateUsecaseImpl) GetTemplate(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.TodoTemplate, error) {
+	existing, err := u.repo.GetTemplate(ctx, uuid)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	if existing.UserID != userID {
+		u.logger.Debug("Forbidden when access template")
+		return nil, ErrForbidden
+	}
+
+	u.resolveImages(ctx, existing)
+
+	return existing, nil
+}
+
+func (u *TemplateUsecaseImpl) GetTemplates(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID) ([]*models.TodoTemplate, error) {
+	p := pagination.NewPagination(params)
+
+	templates, err := u.repo.GetTemplates(ctx, p, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	u.resolveImages(ctx, templates...)
+
+	return templates, nil
+}
+
+func (u *TemplateUsecaseImpl) DeleteTemplate(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error {
+	existing, err := u.GetTemplate(ctx, userID, uuid)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteTemplate(ctx, uuid); err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	releaseFiles(ctx, u.files, u.blob, u.logger, existing.Images)
+
+	return nil
+}
+
+// InstantiateTemplate creates a todo from a template, replacing {{name}}
+// placeholders with request.Variables or the built-in date variables.
+func (u *TemplateUsecaseImpl) InstantiateTemplate(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, request *models.TemplateInstantiateRequest) (*models.Todo, error) {
+	template, err := u.GetTemplate(ctx, userID, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := builtinVariables(time.Now())
+	for key, value := range request.Variables {
+		variables[key] = value
+	}
+
+	items := make([]string, len(template.Todo))
+	for i, item := range template.Todo {
+		items[i] = substitute(item, variables)
+	}
+
+	images, err := shareImages(ctx, u.blob, userID, template.Images)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	todo, err := u.todoUsecase.AddTodo(ctx, &models.TodoRequest{
+		Title:    substitute(template.Title, variables),
+		Todo:     items,
+		Check:    []string{},
+		Images:   images,
+		ParentID: request.ParentID,
+		UserID:   userID,
+	})
+	if err != nil {
+		// AddTodo has released the images
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+func builtinVariables(now time.Time) map[string]string {
+	year, week := now.ISOWeek()
+
+	return map[string]string{
+		"date":     now.Format("2006-01-02"),
+		"time":     now.Format("15:04"),
+		"datetime": now.Format(time.RFC3339),
+		"year":     strconv.Itoa(now.Year()),
+		"month":    now.Format("01"),
+		"day":      now.Format("02"),
+		"weekday":  now.Weekday().String(),
+		"week":     fmt.Sprintf("%d-W%02d", year, week),
+	}
+}
+
+// substitute replaces known placeholders and leaves unknown ones untouched.
+func substitute(text string, variables map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -9,9 +9,25 @@ import (
 )
 
 type TodoUsecase interface {
-	AddTodo(ctx context.Context, todo *models.TodoRequest) error
-	UpdateTodo(ctx context.Context, todo *models.Todo) error
-	GetTodo(ctx context.Context, uuid uuid.UUID) (*models.Todo, error)
-	GetTodos(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, key string, value ...interface{}) ([]*models.Todo, error)
-	DeleteTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error
+	AddTodo(ctx context.Context, todo *models.TodoRequest) (*models.Todo, error)
+	UpdateTodo(ctx context.Context, actorID uuid.UUID, todo *models.Todo) error
+	// GetTodo returns the user's todo with signed URLs of its images.
+	GetTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error)
+	GetTodos(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, filter *models.TodoFilter) ([]*models.Todo, error)
+	GetTodoTree(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error)
+	MoveTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, request *models.TodoMoveRequest) error
+	DeleteTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, mode string) error
+	ArchiveTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error)
+	UnarchiveTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error)
+	DuplicateTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, request *models.TodoDuplicateRequest) (*models.Todo, error)
+	BulkTodos(ctx context.Context, request *models.TodoBulkRequest) (*models.TodoBulkResult, error)
+
+	// AddImages appends uploaded images to the todo.
+	AddImages(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, images []string) (*models.Todo, error)
+	// RemoveImage removes an image, given by its key or the key without
+	// extension, from the todo and deletes it.
+	RemoveImage(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, image string) (*models.Todo, error)
+	// ReorderImages puts the images of the todo in the order of the request,
+	// which must list each of them once.
+	ReorderImages(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, request *models.TodoImagesRequest) (*models.Todo, error)
 }
@@ -5,84 +5,276 @@ import (
 	"errors"
 	"practice/internal/todo/repository"
 	"practice/models"
+	"practice/pkg/bus"
 	"practice/pkg/logger"
 	"practice/pkg/pagination"
+	"practice/pkg/rank"
+	"practice/pkg/search"
+	"practice/pkg/storage"
 	"practice/pkg/validator"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrNotFound  = errors.New("todo not found")
-	ErrForbidden = errors.New("Forbidden")
+	ErrNotFound      = errors.New("todo not found")
+	ErrForbidden     = errors.New("Forbidden")
+	ErrInvalidParent = errors.New("invalid parent todo")
+	ErrInvalidAnchor = errors.New("invalid move anchor")
+	ErrInvalidLang   = errors.New("unsupported search language")
+	ErrTooManyImages = errors.New("too many images")
+	ErrImageNotFound = errors.New("image not found")
+	ErrInvalidImages = errors.New("images must list every image of the todo once")
 )
 
 type TodoUsecaseImpl struct {
 	repo      repository.TodoRepo
+	files     repository.FileRepo
+	blob      storage.Blob
 	validator *validator.CustomValidator
 	logger    *logger.Logger
 }
 
-func NewTodoUsecase(repo repository.TodoRepo, validator *validator.CustomValidator, logger *logger.Logger) TodoUsecase {
+func NewTodoUsecase(repo repository.TodoRepo, files repository.FileRepo, blob storage.Blob, validator *validator.CustomValidator, logger *logger.Logger) TodoUsecase {
 	return &TodoUsecaseImpl{
 		repo:      repo,
+		files:     files,
+		blob:      blob,
 		validator: validator,
 		logger:    logger,
 	}
 }
 
-func (u *TodoUsecaseImpl) AddTodo(ctx context.Context, todo *models.TodoRequest) error {
+// AddTodo stores a new todo. Its images are fresh uploads or copies that
+// nothing else references, so they are released when the todo cannot be
+// added. Fresh uploads are left to the upload sweeper.
+func (u *TodoUsecaseImpl) AddTodo(ctx context.Context, todo *models.TodoRequest) (*models.Todo, error) {
+	todoModel, err := u.addTodo(ctx, todo)
+	if err != nil {
+		releaseFiles(ctx, u.files, u.blob, u.logger, todo.Images)
+		return nil, err
+	}
+
+	return todoModel, nil
+}
+
+func (u *TodoUsecaseImpl) addTodo(ctx context.Context, todo *models.TodoRequest) (*models.Todo, error) {
 	err := u.validator.Validate(todo)
 	if err != nil {
 		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	if err := checkImageCount(todo.Images); err != nil {
+		return nil, err
+	}
+
+	if todo.ParentID != nil {
+		if err := u.checkParent(ctx, todo.UserID, *todo.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	var todoModel *models.Todo
+	err = u.repo.Transaction(ctx, func(repo repository.TodoRepo) error {
+		todoModel, err = createTodo(ctx, repo, todo)
 		return err
+	})
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	u.resolveImages(ctx, todoModel)
+
+	return todoModel, nil
+}
+
+// createTodo stores a new todo at the end of the user's list together with
+// its todo.created event. repo should be transactional, so the user's
+// positions stay locked until the todo is committed.
+func createTodo(ctx context.Context, repo repository.TodoRepo, todo *models.TodoRequest) (*models.Todo, error) {
+	if err := repo.LockPositions(ctx, todo.UserID); err != nil {
+		return nil, err
+	}
+
+	last, err := repo.GetLastPosition(ctx, todo.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := rank.Between(last, "")
+	if err != nil {
+		return nil, err
 	}
 
 	todoModel := &models.Todo{
-		Title:  todo.Title,
-		Todo:   todo.Todo,
-		Check:  todo.Check,
-		Images: todo.Images,
-		UserID: todo.UserID,
+		Title:    todo.Title,
+		Todo:     todo.Todo,
+		Check:    todo.Check,
+		Images:   todo.Images,
+		DueAt:    todo.DueAt,
+		UserID:   todo.UserID,
+		ParentID: todo.ParentID,
+		Position: position,
+	}
+	applyStatus(todoModel, nil)
+
+	if err := repo.AddTodo(ctx, todoModel); err != nil {
+		return nil, err
 	}
 
-	return u.repo.AddTodo(ctx, todoModel)
+	if err := repo.AddEvent(ctx, todoModel.ID, bus.NewEvent(ctx, models.TodoCreated{Todo: todoModel})); err != nil {
+		return nil, err
+	}
+
+	return todoModel, nil
 }
 
-func (u *TodoUsecaseImpl) UpdateTodo(ctx context.Context, todo *models.Todo) error {
+// UpdateTodo saves the todo and, in the same transaction, its todo.updated
+// event and a todo.completed or todo.reopened event when the status moves
+// into or out of done. Only the owner may update a todo. Images the todo no
+// longer has are deleted, and images it gains must be uploads of its owner.
+func (u *TodoUsecaseImpl) UpdateTodo(ctx context.Context, actorID uuid.UUID, todo *models.Todo) error {
 	err := u.validator.Validate(todo)
 	if err != nil {
 		u.logger.Debug(err.Error())
 		return err
 	}
 
-	existing, err := u.repo.GetTodo(ctx, todo.ID)
+	if err := checkImageCount(todo.Images); err != nil {
+		return err
+	}
+
+	var dropped []string
+	err = u.repo.Transaction(ctx, func(repo repository.TodoRepo) error {
+		existing, err := repo.GetTodo(ctx, todo.ID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+		if existing.UserID != actorID {
+			return ErrForbidden
+		}
+
+		if err := checkImageOwner(ctx, repo, existing.UserID, droppedImages(todo.Images, existing.Images)); err != nil {
+			return err
+		}
+		dropped = droppedImages(existing.Images, todo.Images)
+
+		todo.UserID = existing.UserID
+		todo.ParentID = existing.ParentID
+		todo.Position = existing.Position
+		todo.ArchivedAt = existing.ArchivedAt
+		todo.CreatedAt = existing.CreatedAt
+		// todo.CreatedBy = existing.CreatedBy
+
+		transition := applyStatus(todo, existing)
+
+		if err := repo.UpdateTodo(ctx, todo); err != nil {
+			return err
+		}
+
+		err = repo.AddEvent(ctx, todo.ID, bus.NewEvent(ctx, models.TodoChange{
+			ActorID: actorID,
+			Before:  existing,
+			After:   todo,
+		}))
+		if err != nil {
+			return err
+		}
+
+		return addTransitionEvent(ctx, repo, transition, todo)
+	})
 	if err != nil {
 		u.logger.Debug(err.Error())
 		return err
 	}
 
-	todo.UserID = existing.UserID
-	todo.CreatedAt = existing.CreatedAt
-	// todo.CreatedBy = existing.CreatedBy
+	releaseFiles(ctx, u.files, u.blob, u.logger, dropped)
 
-	return u.repo.UpdateTodo(ctx, todo)
+	return nil
 }
 
-func (u *TodoUsecaseImpl) GetTodo(ctx context.Context, uuid uuid.UUID) (*models.Todo, error) {
-	existing, err := u.repo.GetTodo(ctx, uuid)
+// checkImageOwner makes sure the images are uploads of userID, so a todo
+// cannot take over, and later delete, files of someone else.
+func checkImageOwner(ctx context.Context, repo repository.TodoRepo, userID uuid.UUID, images []string) error {
+	if len(images) == 0 {
+		return nil
+	}
 
+	uploads, err := repo.GetUploads(ctx, images)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			u.logger.Debug(err.Error())
-			return nil, ErrNotFound
+		return err
+	}
+
+	owned := make(map[string]bool, len(uploads))
+	for _, upload := range uploads {
+		if upload.UserID == userID {
+			owned[upload.Key] = true
+		}
+	}
+	for _, image := range images {
+		if !owned[image] {
+			return ErrForbidden
 		}
+	}
 
-		u.logger.Debug(err.Error())
+	return nil
+}
+
+// deletedFiles returns the images and attachments of the todos a delete in
+// mode removes.
+func deletedFiles(ctx context.Context, repo repository.TodoRepo, todo *models.Todo, mode string) ([]string, error) {
+	todos := []*models.Todo{todo}
+	if mode == models.DeleteCascade {
+		subtree, err := repo.GetSubtree(ctx, todo.ID)
+		if err != nil {
+			return nil, err
+		}
+		todos = subtree
+	}
+
+	var keys []string
+	ids := make([]uuid.UUID, len(todos))
+	for i, todo := range todos {
+		keys = append(keys, todo.Images...)
+		ids[i] = todo.ID
+	}
+
+	attachments, err := repo.GetAttachmentKeys(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(keys, attachments...), nil
+}
+
+func addTransitionEvent(ctx context.Context, repo repository.TodoRepo, transition string, todo *models.Todo) error {
+	switch transition {
+	case models.TransitionCompleted:
+		return repo.AddEvent(ctx, todo.ID, bus.NewEvent(ctx, models.TodoCompleted{Todo: todo}))
+	case models.TransitionReopened:
+		return repo.AddEvent(ctx, todo.ID, bus.NewEvent(ctx, models.TodoReopened{Todo: todo}))
+	default:
+		return nil
+	}
+}
+
+func (u *TodoUsecaseImpl) GetTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error) {
+	existing, err := u.getOwnTodo(ctx, userID, uuid)
+	if err != nil {
 		return nil, err
 	}
 
+	u.resolveImages(ctx, existing)
+
 	return existing, nil
 }
 
@@ -90,30 +282,469 @@ func (u *TodoUsecaseImpl) GetTodos(
 	ctx context.Context,
 	params *pagination.PaginationParams,
 	userID uuid.UUID,
-	key string,
-	value ...interface{},
+	filter *models.TodoFilter,
 ) ([]*models.Todo, error) {
+	if err := checkFilter(u.validator, filter); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
 	p := pagination.NewPagination(params)
 
-	return u.repo.GetTodos(ctx, p, &userID, key, value...)
+	// search results are ranked unless the caller asks for another order
+	if filter.Search != "" && (params == nil || params.Sort == "") {
+		p.Sort = "rank DESC"
+	}
+
+	// positions are compared byte-wise, independent of the database locale
+	switch strings.ToLower(strings.TrimSpace(p.Sort)) {
+	case "position", "position asc":
+		p.Sort = `position COLLATE "C" ASC`
+	case "position desc":
+		p.Sort = `position COLLATE "C" DESC`
+	}
+
+	todos, err := u.repo.GetTodos(ctx, p, &userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	u.resolveImages(ctx, todos...)
+
+	return todos, nil
 }
 
-func (u *TodoUsecaseImpl) DeleteTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error {
-	existing, err := u.repo.GetTodo(ctx, uuid)
+// checkFilter rejects filters with invalid fields or an unknown search
+// language.
+func checkFilter(validator *validator.CustomValidator, filter *models.TodoFilter) error {
+	if err := validator.Validate(filter); err != nil {
+		return err
+	}
+
+	if filter.Language != "" && !search.IsLanguage(filter.Language) {
+		return ErrInvalidLang
+	}
+
+	return nil
+}
+
+// GetTodoTree returns the todo with its descendants nested under Children.
+// Every node carries a Progress that rolls up the checklists of its subtree.
+func (u *TodoUsecaseImpl) GetTodoTree(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error) {
+	if _, err := u.getOwnTodo(ctx, userID, uuid); err != nil {
+		return nil, err
+	}
+
+	todos, err := u.repo.GetSubtree(ctx, uuid)
 	if err != nil {
 		u.logger.Debug(err.Error())
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, ErrNotFound
+	}
+
+	byID := make(map[string]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID.String()] = todo
+	}
+
+	root := todos[0]
+	for _, todo := range todos[1:] {
+		if parent, ok := byID[todo.ParentID.String()]; ok {
+			parent.Children = append(parent.Children, todo)
+		}
+	}
+
+	rollupProgress(root)
+	u.resolveImages(ctx, root)
+
+	return root, nil
+}
+
+// MoveTodo re-parents and/or reorders a todo together with its subtree.
+// With Before/After anchors the todo is placed next to them and adopts
+// their parent; otherwise it keeps its position and moves under ParentID,
+// where a nil parent makes it a top-level todo.
+func (u *TodoUsecaseImpl) MoveTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, request *models.TodoMoveRequest) error {
+	existing, err := u.getOwnTodo(ctx, userID, uuid)
+	if err != nil {
 		return err
 	}
 
-	if existing.UserID != userID {
-		u.logger.Debug("Forbidden when delete")
-		return ErrForbidden
+	parentID, position := request.ParentID, existing.Position
+	if request.Before != nil || request.After != nil {
+		parentID, position, err = u.anchorPosition(ctx, userID, uuid, request)
+		if err != nil {
+			return err
+		}
+	}
+
+	if parentID != nil {
+		if err := u.checkParent(ctx, userID, *parentID); err != nil {
+			return err
+		}
+
+		subtree, err := u.repo.GetSubtree(ctx, uuid)
+		if err != nil {
+			u.logger.Debug(err.Error())
+			return err
+		}
+
+		for _, todo := range subtree {
+			if todo.ID == *parentID {
+				u.logger.Debug("cannot move todo into its own subtree")
+				return ErrInvalidParent
+			}
+		}
+	}
+
+	return u.repo.MoveTodo(ctx, uuid, parentID, position)
+}
+
+// anchorPosition computes a position between the requested anchors. A
+// missing anchor is filled in with the neighbour of the other one, so only
+// the moved todo ever gets a new position.
+func (u *TodoUsecaseImpl) anchorPosition(ctx context.Context, userID uuid.UUID, id uuid.UUID, request *models.TodoMoveRequest) (*uuid.UUID, string, error) {
+	var parentID *uuid.UUID
+	var lower, upper string
+
+	if request.After != nil {
+		anchor, err := u.getOwnTodo(ctx, userID, *request.After)
+		if err != nil || anchor.ID == id {
+			return nil, "", ErrInvalidAnchor
+		}
+		lower, parentID = anchor.Position, anchor.ParentID
+	}
+
+	if request.Before != nil {
+		anchor, err := u.getOwnTodo(ctx, userID, *request.Before)
+		if err != nil || anchor.ID == id {
+			return nil, "", ErrInvalidAnchor
+		}
+		if request.After != nil && !sameParent(parentID, anchor.ParentID) {
+			return nil, "", ErrInvalidAnchor
+		}
+		upper, parentID = anchor.Position, anchor.ParentID
 	}
 
-	if existing == nil {
-		u.logger.Debug("todo not found when delete")
-		return ErrNotFound
+	var err error
+	if request.After == nil {
+		lower, err = u.repo.GetAdjacentPosition(ctx, userID, id, upper, true)
+	} else if request.Before == nil {
+		upper, err = u.repo.GetAdjacentPosition(ctx, userID, id, lower, false)
+	}
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, "", err
+	}
+
+	position, err := rank.Between(lower, upper)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, "", ErrInvalidAnchor
+	}
+
+	return parentID, position, nil
+}
+
+// DeleteTodo deletes the todo, or with DeleteCascade its subtree, together
+// with the images and attachments of the deleted todos.
+func (u *TodoUsecaseImpl) DeleteTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, mode string) error {
+	existing, err := u.getOwnTodo(ctx, userID, uuid)
+	if err != nil {
+		return err
+	}
+
+	files, err := deletedFiles(ctx, u.repo, existing, mode)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteTodo(ctx, uuid, mode); err != nil {
+		return err
+	}
+
+	releaseFiles(ctx, u.files, u.blob, u.logger, files)
+
+	return nil
+}
+
+// ArchiveTodo archives a todo together with its subtree, hiding them from
+// listings unless archived todos are asked for.
+func (u *TodoUsecaseImpl) ArchiveTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error) {
+	now := time.Now()
+	return u.setArchived(ctx, userID, uuid, &now)
+}
+
+// UnarchiveTodo restores an archived todo together with its subtree.
+func (u *TodoUsecaseImpl) UnarchiveTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error) {
+	return u.setArchived(ctx, userID, uuid, nil)
+}
+
+func (u *TodoUsecaseImpl) setArchived(ctx context.Context, userID uuid.UUID, id uuid.UUID, archivedAt *time.Time) (*models.Todo, error) {
+	if _, err := u.getOwnTodo(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	subtree, err := u.repo.GetSubtree(ctx, id)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(subtree))
+	for i, todo := range subtree {
+		ids[i] = todo.ID
+	}
+
+	if err := u.repo.ArchiveTodos(ctx, ids, archivedAt); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	return u.GetTodo(ctx, userID, id)
+}
+
+// DuplicateTodo copies a todo next to the original. Checks and images are
+// only carried over when requested; the copy shares the files of the
+// images.
+func (u *TodoUsecaseImpl) DuplicateTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID, request *models.TodoDuplicateRequest) (*models.Todo, error) {
+	existing, err := u.getOwnTodo(ctx, userID, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	check := []string{}
+	if request.KeepChecks {
+		check = existing.Check
+	}
+
+	images := pq.StringArray{}
+	if request.Images {
+		images, err = shareImages(ctx, u.blob, userID, existing.Images)
+		if err != nil {
+			u.logger.Debug(err.Error())
+			return nil, err
+		}
+	}
+
+	todo, err := u.AddTodo(ctx, &models.TodoRequest{
+		Title:    existing.Title,
+		Todo:     existing.Todo,
+		Check:    check,
+		Images:   images,
+		DueAt:    existing.DueAt,
+		ParentID: existing.ParentID,
+		UserID:   userID,
+	})
+	if err != nil {
+		// AddTodo has released the images
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// BulkTodos runs the requested operations over many todos in a single
+// transaction. Every todo is applied within its own savepoint, so a todo
+// that is missing, not owned by the user or fails to update is reported in
+// the result without undoing the others.
+func (u *TodoUsecaseImpl) BulkTodos(ctx context.Context, request *models.TodoBulkRequest) (*models.TodoBulkResult, error) {
+	err := u.validator.Validate(request)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	if len(request.IDs) == 0 && request.Filter == nil {
+		return nil, validator.NewValidationError("ids", "Either ids or filter is required.")
+	}
+
+	if request.Filter != nil && request.Filter.Language != "" && !search.IsLanguage(request.Filter.Language) {
+		return nil, ErrInvalidLang
+	}
+
+	mode := request.DeleteMode
+	if mode == "" {
+		mode = models.DeleteReparent
+	}
+
+	result := &models.TodoBulkResult{
+		UserID:     request.UserID,
+		Operations: request.Operations,
+		Items:      []models.TodoBulkItemResult{},
+	}
+
+	var released []string
+	err = u.repo.Transaction(ctx, func(repo repository.TodoRepo) error {
+		ids := request.IDs
+		if len(ids) == 0 {
+			todos, err := repo.FindTodos(ctx, request.UserID, request.Filter)
+			if err != nil {
+				return err
+			}
+			for _, todo := range todos {
+				ids = append(ids, todo.ID)
+			}
+		}
+
+		for _, id := range ids {
+			item := models.TodoBulkItemResult{ID: id, Status: models.BulkStatusOK}
+
+			var dropped []string
+			err := repo.Transaction(ctx, func(repo repository.TodoRepo) error {
+				var err error
+				dropped, err = applyBulk(ctx, repo, request.UserID, &item, request.Operations, mode)
+				return err
+			})
+			switch {
+			case err == nil:
+				result.Succeeded++
+				released = append(released, dropped...)
+			case errors.Is(err, ErrNotFound):
+				item.Status = models.BulkStatusNotFound
+			case errors.Is(err, ErrForbidden):
+				item.Status = models.BulkStatusForbidden
+			default:
+				u.logger.Debug(err.Error())
+				item.Status = models.BulkStatusFailed
+				item.Error = err.Error()
+			}
+
+			if item.Status != models.BulkStatusOK {
+				result.Failed++
+			}
+			result.Items = append(result.Items, item)
+		}
+
+		return nil
+	})
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	releaseFiles(ctx, u.files, u.blob, u.logger, released)
+
+	result.Total = len(result.Items)
+
+	return result, nil
+}
+
+// applyBulk applies the operations to the todo of item and records a status
+// transition they cause on it. It returns the files the todo lost.
+func applyBulk(ctx context.Context, repo repository.TodoRepo, userID uuid.UUID, item *models.TodoBulkItemResult, operations []string, mode string) ([]string, error) {
+	id := item.ID
+	todo, err := repo.GetTodo(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if todo.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	previous := *todo
+	for _, operation := range operations {
+		switch operation {
+		case models.BulkCheckAll:
+			todo.Check = append(pq.StringArray{}, todo.Todo...)
+		case models.BulkUncheckAll:
+			todo.Check = pq.StringArray{}
+		case models.BulkClearImages:
+			todo.Images = pq.StringArray{}
+		case models.BulkDelete:
+			files, err := deletedFiles(ctx, repo, &previous, mode)
+			if err != nil {
+				return nil, err
+			}
+			return files, repo.DeleteTodo(ctx, id, mode)
+		}
+	}
+
+	transition := applyStatus(todo, &previous)
+	if err := repo.UpdateTodo(ctx, todo); err != nil {
+		return nil, err
+	}
+
+	if err := addTransitionEvent(ctx, repo, transition, todo); err != nil {
+		return nil, err
+	}
+
+	item.Transition = transition
+
+	return droppedImages(previous.Images, todo.Images), nil
+}
+
+// getOwnTodo loads the user's todo without signing its image URLs, which
+// only responses need.
+func (u *TodoUsecaseImpl) getOwnTodo(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Todo, error) {
+	existing, err := ownTodo(ctx, u.repo, userID, uuid)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// ownTodo loads a todo and makes sure it belongs to the user. Comments,
+// activities and attachments are only reachable through their todo, so they
+// share this check.
+func ownTodo(ctx context.Context, repo repository.TodoRepo, userID uuid.UUID, todoID uuid.UUID) (*models.Todo, error) {
+	todo, err := repo.GetTodo(ctx, todoID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if todo.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	return todo, nil
+}
+
+func (u *TodoUsecaseImpl) checkParent(ctx context.Context, userID uuid.UUID, parentID uuid.UUID) error {
+	if _, err := u.getOwnTodo(ctx, userID, parentID); err != nil {
+		u.logger.Debug(err.Error())
+		return ErrInvalidParent
+	}
+
+	return nil
+}
+
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// rollupProgress fills in Progress for todo and all of its children and
+// returns the item counts of the whole subtree.
+func rollupProgress(todo *models.Todo) (int, int) {
+	items, done := checklistCount(todo)
+
+	for _, child := range todo.Children {
+		childItems, childDone := rollupProgress(child)
+		items += childItems
+		done += childDone
+	}
+
+	todo.Progress = &models.TodoProgress{
+		Items:   items,
+		Checked: done,
+	}
+	if items > 0 {
+		todo.Progress.Percent = float64(done) * 100 / float64(items)
 	}
 
-	return u.repo.DeleteTodo(ctx, uuid)
+	return items, done
 }
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/storage"
+	"practice/pkg/validator"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// testTodoRepo keeps todos in memory. Methods the tests do not use panic.
+type testTodoRepo struct {
+	repository.TodoRepo
+	todos   map[uuid.UUID]*models.Todo
+	updated []*models.Todo
+}
+
+func (r *testTodoRepo) GetTodo(ctx context.Context, id uuid.UUID) (*models.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *todo
+	return &copied, nil
+}
+
+func (r *testTodoRepo) UpdateTodo(ctx context.Context, todo *models.Todo) error {
+	r.updated = append(r.updated, todo)
+	return nil
+}
+
+func (r *testTodoRepo) Transaction(ctx context.Context, fn func(repo repository.TodoRepo) error) error {
+	return fn(r)
+}
+
+type testFileRepo struct {
+	released []string
+}
+
+func (r *testFileRepo) UnusedFiles(ctx context.Context, keys []string) ([]string, error) {
+	r.released = append(r.released, keys...)
+	return keys, nil
+}
+
+type testBlob struct {
+	storage.Blob
+	deleted []string
+}
+
+func (b *testBlob) Delete(ctx context.Context, key string) error {
+	b.deleted = append(b.deleted, key)
+	return nil
+}
+
+func testLogger(t *testing.T) *logger.Logger {
+	config := logger.DefaultConfig()
+	config.OutputPath = filepath.Join(t.TempDir(), "test.log")
+
+	log, err := logger.NewLogger(config, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func TestUpdateTodoOfOtherUser(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	todo := &models.Todo{
+		Title:  "todo",
+		Todo:   []string{"a"},
+		Check:  []string{"false"},
+		Images: []string{"image"},
+		UserID: owner,
+	}
+	todo.ID = uuid.New()
+
+	repo := &testTodoRepo{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}
+	files := &testFileRepo{}
+	blob := &testBlob{}
+	usecase := NewTodoUsecase(repo, files, blob, validator.NewCustomValidator(), testLogger(t))
+
+	update := &models.Todo{
+		Title: "taken",
+		Todo:  []string{"a"},
+		Check: []string{"false"},
+	}
+	update.ID = todo.ID
+
+	err := usecase.UpdateTodo(context.Background(), other, update)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("UpdateTodo by another user = %v, want %v", err, ErrForbidden)
+	}
+	if len(repo.updated) != 0 {
+		t.Errorf("updated %d todos, want none", len(repo.updated))
+	}
+	if len(files.released) != 0 || len(blob.deleted) != 0 {
+		t.Errorf("released %v and deleted %v, want the images kept", files.released, blob.deleted)
+	}
+}
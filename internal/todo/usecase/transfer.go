@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"practice/models"
+
+	"github.com/google/uuid"
+)
+
+type TransferUsecase interface {
+	CheckFilter(filter *models.TodoFilter) error
+	ExportTodos(ctx context.Context, userID uuid.UUID, filter *models.TodoFilter, format string, w io.Writer) error
+	ImportTodos(ctx context.Context, userID uuid.UUID, format string, r io.Reader, dryRun bool) (*models.TodoImportResult, error)
+}
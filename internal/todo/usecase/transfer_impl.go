@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"practice/internal/todo/repository"
+	"practice/models"
+	"practice/pkg/logger"
+	"practice/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+const (
+	exportBatchSize = 200
+	maxImportRows   = 1000
+)
+
+type TransferUsecaseImpl struct {
+	repo      repository.TodoRepo
+	validator *validator.CustomValidator
+	logger    *logger.Logger
+}
+
+func NewTransferUsecase(repo repository.TodoRepo, validator *validator.CustomValidator, logger *logger.Logger) TransferUsecase {
+	return &TransferUsecaseImpl{
+		repo:      repo,
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+// ExportTodos writes the user's todos matching filter to w, in list order.
+func (u *TransferUsecaseImpl) ExportTodos(ctx context.Context, userID uuid.UUID, filter *models.TodoFilter, format string, w io.Writer) error {
+	if err := u.CheckFilter(filter); err != nil {
+		return err
+	}
+
+	encoder, err := newTodoEncoder(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := encoder.Begin(); err != nil {
+		return err
+	}
+
+	err = u.repo.FindTodosInBatches(ctx, userID, filter, exportBatchSize, func(todos []*models.Todo) error {
+		for _, todo := range todos {
+			if err := encoder.Encode(todo); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	return encoder.End()
+}
+
+// CheckFilter rejects the filters GetTodos rejects. Exports are streamed,
+// so their filter is checked before the response starts.
+func (u *TransferUsecaseImpl) CheckFilter(filter *models.TodoFilter) error {
+	if err := checkFilter(u.validator, filter); err != nil {
+		u.logger.Debug(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ImportTodos validates every row with CustomValidator and creates the
+// valid ones in a single transaction. Invalid rows are skipped and listed
+// in the result. With dryRun nothing is written.
+func (u *TransferUsecaseImpl) ImportTodos(ctx context.Context, userID uuid.UUID, format string, r io.Reader, dryRun bool) (*models.TodoImportResult, error) {
+	rows, err := decodeTodos(format, r)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	if len(rows) > maxImportRows {
+		return nil, validator.NewValidationError("file", "Must not contain more than 1000 todos.")
+	}
+
+	result := &models.TodoImportResult{
+		Format: format,
+		DryRun: dryRun,
+		Total:  len(rows),
+		Errors: []models.TodoImportRowError{},
+	}
+
+	var valid []*models.TodoRequest
+	for _, row := range rows {
+		request := row.Request
+		request.UserID = userID
+		request.ParentID = nil
+		request.Images = nil
+		if request.Check == nil {
+			request.Check = []string{}
+		}
+
+		if row.Err != nil {
+			result.Errors = append(result.Errors, models.TodoImportRowError{
+				Row:     row.Row,
+				Line:    row.Line,
+				Message: row.Err.Error(),
+			})
+			continue
+		}
+
+		if err := u.validator.Validate(request); err != nil {
+			result.Errors = append(result.Errors, models.TodoImportRowError{
+				Row:     row.Row,
+				Line:    row.Line,
+				Message: "validation failed",
+				Errors:  err,
+			})
+			continue
+		}
+
+		valid = append(valid, request)
+	}
+
+	result.Failed = len(result.Errors)
+	if dryRun {
+		result.Imported = len(valid)
+		return result, nil
+	}
+
+	err = u.repo.Transaction(ctx, func(repo repository.TodoRepo) error {
+		for _, request := range valid {
+			if _, err := createTodo(ctx, repo, request); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	result.Imported = len(valid)
+
+	return result, nil
+}
@@ -1,8 +1,13 @@
 package handler
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"practice/pkg/bus"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 type UserHandler interface {
+	bus.EventHandler
 	Register(c *fiber.Ctx) error
 	Login(c *fiber.Ctx) error
 	GetUser(c *fiber.Ctx) error
@@ -25,7 +25,7 @@ func NewUserHandler(usecase usecase.UserUsecase, logger *logger.Logger, event *b
 }
 
 func (h *UserHandlerImpl) Handle(event bus.Event) {
-	h.logger.Info("Todo event: %s - %v", event.Type, event.Payload)
+	h.logger.Info("user event", "type", event.Type, "id", event.Metadata.ID)
 }
 
 func (h *UserHandlerImpl) Register(c *fiber.Ctx) error {
@@ -34,10 +34,13 @@ func (h *UserHandlerImpl) Register(c *fiber.Ctx) error {
 		return c.Next()
 	}
 
-	if err := h.usecase.Register(c.Context(), request); err != nil {
+	user, err := h.usecase.Register(c.Context(), request)
+	if err != nil {
 		return c.Next()
 	}
 
+	bus.Publish(h.event, c.Context(), models.UserRegistered{ID: user.ID, Name: user.Name, Email: user.Email})
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "success",
 	})
@@ -13,14 +13,17 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func Route(f fiber.Router, db *config.DB, logger *logger.Logger) {
+func Route(f fiber.Router, db *config.DB, logger *logger.Logger, event *bus.EventBus) {
 	validator := validator.NewCustomValidator()
-	event := bus.NewEventBus()
 
 	repo := repository.NewUserRepo(db.Instance())
 	usecase := usecase.NewUserUsecase(repo, validator, logger)
 	handler := handler.NewUserHandler(usecase, logger, event)
 
+	event.Register(
+		bus.OnPattern("user.*", handler),
+	)
+
 	f.Post("/register", handler.Register)
 	f.Post("/login", handler.Login)
 
@@ -29,17 +29,17 @@ func NewUserUsecase(repo repository.UserRepo, validator *validator.CustomValidat
 	}
 }
 
-func (u *UserUsecaseImpl) Register(ctx context.Context, user *models.UserRegister) error {
+func (u *UserUsecaseImpl) Register(ctx context.Context, user *models.UserRegister) (*models.User, error) {
 	err := u.validator.Validate(user)
 	if err != nil {
 		u.logger.Debug(err.Error())
-		return err
+		return nil, err
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		u.logger.Debug(err.Error())
-		return err
+		return nil, err
 	}
 	user.Password = string(hash)
 
@@ -51,10 +51,10 @@ func (u *UserUsecaseImpl) Register(ctx context.Context, user *models.UserRegiste
 
 	if err := u.repo.AddUser(ctx, userModel); err != nil {
 		u.logger.Debug(err.Error())
-		return err
+		return nil, err
 	}
 
-	return nil
+	return userModel, nil
 }
 
 func (u *UserUsecaseImpl) Login(ctx context.Context, user *models.UserLogin) (string, error) {
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"practice/internal/webhook/usecase"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errorStatuses = []middleware.ErrorStatus{
+	{Err: usecase.ErrNotFound, Status: fiber.StatusNotFound},
+	{Err: usecase.ErrDeliveryNotFound, Status: fiber.StatusNotFound},
+}
+
+// fail maps usecase errors to their HTTP responses.
+func fail(c *fiber.Ctx, logger *logger.Logger, err error) error {
+	return middleware.Fail(c, logger, err, errorStatuses)
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"practice/pkg/bus"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler interface {
+	bus.ContextHandler
+
+	GetWebhooks(c *fiber.Ctx) error
+	GetWebhook(c *fiber.Ctx) error
+	AddWebhook(c *fiber.Ctx) error
+	UpdateWebhook(c *fiber.Ctx) error
+	DeleteWebhook(c *fiber.Ctx) error
+	GetDeliveries(c *fiber.Ctx) error
+	Redeliver(c *fiber.Ctx) error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookRepo interface {
+	AddWebhook(ctx context.Context, webhook *models.Webhook) error
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context, userID uuid.UUID) ([]*models.Webhook, error)
+	GetActiveWebhooks(ctx context.Context, userID uuid.UUID) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error
+
+	AddDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, webhookID uuid.UUID, uuid uuid.UUID) (*models.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, params *pagination.Pagination, webhookID uuid.UUID) ([]*models.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, maxFailures int) (bool, error)
+	CleanupDeliveries(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/pagination"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) WebhookRepo {
+	return &WebhookRepoImpl{
+		db: db,
+	}
+}
+
+func (r *WebhookRepoImpl) AddWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Model(&models.Webhook{}).Create(webhook).Error
+}
+
+func (r *WebhookRepoImpl) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Model(&models.Webhook{}).
+		Where("id = ?", webhook.ID).
+		Select("url", "events", "secret", "active", "failures", "disabled_at", "updated_at").
+		Updates(webhook).Error
+}
+
+func (r *WebhookRepoImpl) GetWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Webhook, error) {
+	var webhook *models.Webhook
+	err := r.db.WithContext(ctx).Model(&models.Webhook{}).First(&webhook, "id = ? AND user_id = ?", uuid, userID).Error
+	return webhook, err
+}
+
+func (r *WebhookRepoImpl) GetWebhooks(ctx context.Context, userID uuid.UUID) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	err := r.db.WithContext(ctx).Model(&models.Webhook{}).
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *WebhookRepoImpl) GetActiveWebhooks(ctx context.Context, userID uuid.UUID) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	err := r.db.WithContext(ctx).Model(&models.Webhook{}).
+		Where("user_id = ? AND active", userID).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// DeleteWebhook deletes the webhook together with its delivery log.
+func (r *WebhookRepoImpl) DeleteWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", uuid, userID).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Where("webhook_id = ?", uuid).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+func (r *WebhookRepoImpl) AddDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Create(deliveries).Error
+}
+
+func (r *WebhookRepoImpl) GetDelivery(ctx context.Context, webhookID uuid.UUID, uuid uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery *models.WebhookDelivery
+	err := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).First(&delivery, "id = ? AND webhook_id = ?", uuid, webhookID).Error
+	return delivery, err
+}
+
+func (r *WebhookRepoImpl) GetDeliveries(ctx context.Context, params *pagination.Pagination, webhookID uuid.UUID) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+
+	query := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID)
+
+	paginated, err := pagination.Paginate(&models.WebhookDelivery{}, params, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := paginated.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDeliveries locks pending deliveries that are due, for active
+// webhooks, and pushes their next attempt back by lease so other instances
+// skip them while they are sent. A delivery whose sender dies is attempted
+// again once the lease has passed.
+func (r *WebhookRepoImpl) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Raw(`
+			SELECT d.* FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id AND w.active
+			WHERE d.status = ? AND d.next_attempt_at <= ?
+			ORDER BY d.next_attempt_at
+			LIMIT ?
+			FOR UPDATE OF d SKIP LOCKED`, models.DeliveryPending, now, limit).Scan(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	webhookIDs := make([]uuid.UUID, len(deliveries))
+	for i, delivery := range deliveries {
+		webhookIDs[i] = delivery.WebhookID
+	}
+
+	var webhooks []*models.Webhook
+	if err := r.db.WithContext(ctx).Where("id IN ?", webhookIDs).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*models.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+	for _, delivery := range deliveries {
+		delivery.Webhook = byID[delivery.WebhookID]
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt saves the outcome of an attempt and counts it against the
+// webhook: a success resets its failures, a failure adds one and disables
+// the webhook once maxFailures is reached. It reports whether the webhook
+// was disabled.
+func (r *WebhookRepoImpl) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, maxFailures int) (bool, error) {
+	var disabled bool
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WebhookDelivery{}).
+			Where("id = ?", delivery.ID).
+			Select("status", "attempts", "status_code", "response", "error", "duration_ms", "next_attempt_at", "delivered_at", "updated_at").
+			Updates(delivery).Error
+		if err != nil {
+			return err
+		}
+
+		webhook := tx.Model(&models.Webhook{}).Where("id = ?", delivery.WebhookID)
+		if delivery.Status == models.DeliverySucceeded {
+			return webhook.Update("failures", 0).Error
+		}
+
+		if err := webhook.Update("failures", gorm.Expr("failures + 1")).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Webhook{}).
+			Where("id = ? AND active AND failures >= ?", delivery.WebhookID, maxFailures).
+			Updates(map[string]interface{}{"active": false, "disabled_at": time.Now()})
+		disabled = result.RowsAffected > 0
+
+		return result.Error
+	})
+
+	return disabled, err
+}
+
+func (r *WebhookRepoImpl) CleanupDeliveries(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("status <> ? AND created_at < ?", models.DeliveryPending, before).
+		Delete(&models.WebhookDelivery{}).Error
+}
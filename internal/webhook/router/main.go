@@ -0,0 +1,48 @@
+package router
+
+import (
+	"context"
+	"practice/config"
+	"practice/env"
+	"practice/internal/webhook/handler"
+	"practice/internal/webhook/repository"
+	"practice/internal/webhook/usecase"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/middleware"
+	"practice/pkg/validator"
+	"practice/pkg/webhook"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route mounts the webhook endpoints and delivers webhooks until ctx is
+// cancelled.
+func Route(ctx context.Context, f fiber.Router, db *config.DB, logger *logger.Logger, event *bus.EventBus) {
+	allowed, err := webhook.ParseNetworks(env.WebhookAllowedNetworks)
+	if err != nil {
+		logger.Fatal("invalid WEBHOOK_ALLOWED_NETWORKS", "error", err)
+	}
+
+	validator := validator.NewCustomValidator()
+
+	repo := repository.NewWebhookRepo(db.Instance())
+	usecase := usecase.NewWebhookUsecase(repo, allowed, validator, logger)
+	handler := handler.NewWebhookHandler(usecase, logger)
+
+	event.Register(
+		bus.OnContext("*", handler),
+	)
+
+	go usecase.Run(ctx)
+
+	webhook := f.Group("/webhooks", middleware.JWTAuth())
+
+	webhook.Get("", handler.GetWebhooks)
+	webhook.Post("", handler.AddWebhook)
+	webhook.Get("/:id", handler.GetWebhook)
+	webhook.Put("/:id", handler.UpdateWebhook)
+	webhook.Delete("/:id", handler.DeleteWebhook)
+	webhook.Get("/:id/deliveries", handler.GetDeliveries)
+	webhook.Post("/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+}
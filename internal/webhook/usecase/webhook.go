@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"context"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type WebhookUsecase interface {
+	AddWebhook(ctx context.Context, request *models.WebhookRequest) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, uuid uuid.UUID, request *models.WebhookRequest) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context, userID uuid.UUID) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error
+	GetDeliveries(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, webhookID uuid.UUID) ([]*models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, userID uuid.UUID, webhookID uuid.UUID, deliveryID uuid.UUID) (*models.WebhookDelivery, error)
+
+	// Enqueue stores a delivery of the event for each matching webhook of
+	// the user the event belongs to.
+	Enqueue(ctx context.Context, event bus.Event) error
+	// Deliver attempts one batch of due deliveries and returns its size.
+	Deliver(ctx context.Context) (int, error)
+	// Run delivers until ctx is cancelled.
+	Run(ctx context.Context)
+}
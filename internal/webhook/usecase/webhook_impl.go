@@ -0,0 +1,390 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"practice/internal/webhook/repository"
+	"practice/models"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"practice/pkg/pagination"
+	"practice/pkg/validator"
+	"practice/pkg/webhook"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound         = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+const (
+	deliveryBatchSize   = 50
+	deliveryConcurrency = 10
+	deliveryTimeout     = 10 * time.Second
+	// deliveryLease must outlast sending a whole batch, see ClaimDeliveries.
+	deliveryLease = 2 * time.Minute
+	pollInterval  = time.Second
+
+	maxAttempts = 8
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+
+	// maxFailures is the number of failed attempts in a row, over all of a
+	// webhook's deliveries, after which it is disabled.
+	maxFailures = 20
+
+	deliveryRetention = 30 * 24 * time.Hour
+	cleanupInterval   = time.Hour
+)
+
+type WebhookUsecaseImpl struct {
+	repo      repository.WebhookRepo
+	sender    *webhook.Sender
+	validator *validator.CustomValidator
+	logger    *logger.Logger
+}
+
+// NewWebhookUsecase returns a WebhookUsecase delivering to public
+// addresses and to addresses within allowed.
+func NewWebhookUsecase(repo repository.WebhookRepo, allowed []netip.Prefix, validator *validator.CustomValidator, logger *logger.Logger) WebhookUsecase {
+	return &WebhookUsecaseImpl{
+		repo:      repo,
+		sender:    webhook.NewSender(deliveryTimeout, allowed),
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+func (u *WebhookUsecaseImpl) AddWebhook(ctx context.Context, request *models.WebhookRequest) (*models.Webhook, error) {
+	err := u.validator.Validate(request)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		if secret, err = webhook.NewSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	webhookModel := &models.Webhook{
+		URL:    request.URL,
+		Events: request.Events,
+		Secret: secret,
+		Active: true,
+		UserID: request.UserID,
+	}
+
+	if err := u.repo.AddWebhook(ctx, webhookModel); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	return webhookModel, nil
+}
+
+// UpdateWebhook replaces the URL and event filter, and the secret when one
+// is given. Enabling a webhook resets its failures; its deliveries that
+// were pending when it was disabled are sent again.
+func (u *WebhookUsecaseImpl) UpdateWebhook(ctx context.Context, uuid uuid.UUID, request *models.WebhookRequest) (*models.Webhook, error) {
+	err := u.validator.Validate(request)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	webhookModel, err := u.GetWebhook(ctx, request.UserID, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookModel.URL = request.URL
+	webhookModel.Events = request.Events
+	if request.Secret != "" {
+		webhookModel.Secret = request.Secret
+	}
+
+	if request.Active != nil && *request.Active != webhookModel.Active {
+		webhookModel.Active = *request.Active
+		webhookModel.Failures = 0
+		webhookModel.DisabledAt = nil
+		if !webhookModel.Active {
+			now := time.Now()
+			webhookModel.DisabledAt = &now
+		}
+	}
+
+	if err := u.repo.UpdateWebhook(ctx, webhookModel); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	return webhookModel, nil
+}
+
+func (u *WebhookUsecaseImpl) GetWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) (*models.Webhook, error) {
+	webhookModel, err := u.repo.GetWebhook(ctx, userID, uuid)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return webhookModel, nil
+}
+
+func (u *WebhookUsecaseImpl) GetWebhooks(ctx context.Context, userID uuid.UUID) ([]*models.Webhook, error) {
+	return u.repo.GetWebhooks(ctx, userID)
+}
+
+func (u *WebhookUsecaseImpl) DeleteWebhook(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error {
+	err := u.repo.DeleteWebhook(ctx, userID, uuid)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		if err == gorm.ErrRecordNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (u *WebhookUsecaseImpl) GetDeliveries(ctx context.Context, params *pagination.PaginationParams, userID uuid.UUID, webhookID uuid.UUID) ([]*models.WebhookDelivery, error) {
+	if _, err := u.GetWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	p := pagination.NewPagination(params)
+
+	return u.repo.GetDeliveries(ctx, p, webhookID)
+}
+
+// Redeliver queues a new delivery with the payload of an earlier one,
+// keeping the original in the log.
+func (u *WebhookUsecaseImpl) Redeliver(ctx context.Context, userID uuid.UUID, webhookID uuid.UUID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	if _, err := u.GetWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	original, err := u.repo.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		u.logger.Debug(err.Error())
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+		WebhookID:     webhookID,
+	}
+
+	if err := u.repo.AddDeliveries(ctx, []*models.WebhookDelivery{delivery}); err != nil {
+		u.logger.Debug(err.Error())
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (u *WebhookUsecaseImpl) Enqueue(ctx context.Context, event bus.Event) error {
+	owned, ok := event.Payload.(models.Owned)
+	if !ok {
+		return nil
+	}
+
+	webhooks, err := u.repo.GetActiveWebhooks(ctx, owned.OwnerID())
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	var payload []byte
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	for _, webhookModel := range webhooks {
+		if !subscribed(webhookModel, event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(models.WebhookPayload{
+				ID:            event.Metadata.ID,
+				Type:          event.Type,
+				Timestamp:     event.Metadata.Timestamp,
+				Actor:         event.Metadata.Actor,
+				CorrelationID: event.Metadata.CorrelationID,
+				Data:          event.Payload,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			EventID:       event.Metadata.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+			WebhookID:     webhookModel.ID,
+		})
+	}
+
+	return u.repo.AddDeliveries(ctx, deliveries)
+}
+
+func subscribed(webhookModel *models.Webhook, eventType string) bool {
+	if len(webhookModel.Events) == 0 {
+		return true
+	}
+
+	for _, pattern := range webhookModel.Events {
+		if bus.Match(pattern, eventType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (u *WebhookUsecaseImpl) Deliver(ctx context.Context) (int, error) {
+	deliveries, err := u.repo.ClaimDeliveries(ctx, deliveryBatchSize, deliveryLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	limit := make(chan struct{}, deliveryConcurrency)
+
+	for _, delivery := range deliveries {
+		if delivery.Webhook == nil {
+			continue
+		}
+
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			if err := u.attempt(ctx, delivery); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), errors.Join(errs...)
+}
+
+// attempt sends a delivery and records the outcome. When ctx is cancelled
+// mid-request nothing is recorded, so the delivery is retried after its
+// lease.
+func (u *WebhookUsecaseImpl) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	response, err := u.sender.Send(ctx, webhook.Request{
+		URL:        delivery.Webhook.URL,
+		Secret:     delivery.Webhook.Secret,
+		Event:      delivery.EventType,
+		DeliveryID: delivery.ID.String(),
+		Body:       []byte(delivery.Payload),
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.StatusCode = 0
+	delivery.Response = ""
+	delivery.Error = ""
+	delivery.Duration = 0
+	if response != nil {
+		delivery.StatusCode = response.StatusCode
+		delivery.Response = response.Body
+		delivery.Duration = response.Duration.Milliseconds()
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= maxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		delivery.Error = err.Error()
+		next := now.Add(webhook.Backoff(delivery.Attempts, baseBackoff, maxBackoff))
+		delivery.NextAttemptAt = &next
+	}
+
+	disabled, recordErr := u.repo.RecordAttempt(ctx, delivery, maxFailures)
+	if recordErr != nil {
+		return recordErr
+	}
+
+	if err != nil {
+		u.logger.Debug("webhook delivery failed", "webhook", delivery.WebhookID, "delivery", delivery.ID, "attempts", delivery.Attempts, "error", err)
+	}
+	if disabled {
+		u.logger.Warn("webhook disabled after repeated failures", "webhook", delivery.WebhookID)
+	}
+
+	return nil
+}
+
+func (u *WebhookUsecaseImpl) Run(ctx context.Context) {
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			for {
+				delivered, err := u.Deliver(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						u.logger.Error("webhook delivery failed", "error", err)
+					}
+					break
+				}
+				if delivered < deliveryBatchSize {
+					break
+				}
+			}
+		case <-cleanup.C:
+			if err := u.repo.CleanupDeliveries(ctx, time.Now().Add(-deliveryRetention)); err != nil {
+				u.logger.Error("webhook delivery cleanup failed", "error", err)
+			}
+		}
+	}
+}
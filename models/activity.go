@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	ActivityTodoCreated    = "todo.created"
+	ActivityTitleChanged   = "todo.title_changed"
+	ActivityItemChecked    = "todo.item_checked"
+	ActivityItemUnchecked  = "todo.item_unchecked"
+	ActivityImageAdded     = "todo.image_added"
+	ActivityTodoCompleted  = "todo.completed"
+	ActivityTodoReopened   = "todo.reopened"
+	ActivityCommentCreated = "comment.created"
+)
+
+type TodoActivity struct {
+	Type   string `gorm:"column:type;size:64" json:"type"`
+	Detail string `gorm:"column:detail;type:text" json:"detail"`
+
+	TodoID    uuid.UUID  `gorm:"type:uuid;column:todo_id;index" json:"todo_id"`
+	ActorID   uuid.UUID  `gorm:"type:uuid;column:actor_id" json:"actor_id"`
+	Actor     User       `gorm:"foreignKey:ActorID;references:ID" json:"actor"`
+	CommentID *uuid.UUID `gorm:"type:uuid;column:comment_id" json:"comment_id"`
+	Comment   *Comment   `gorm:"foreignKey:CommentID;references:ID" json:"comment,omitempty"`
+
+	Base
+}
+
+// TodoChange is the payload of a todo.updated event, carrying the state
+// before and after the update so subscribers can tell what changed.
+type TodoChange struct {
+	ActorID uuid.UUID `json:"actor_id"`
+	Before  *Todo     `json:"before"`
+	After   *Todo     `json:"after"`
+}
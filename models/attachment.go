@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// Attachment is a file attached to a todo, such as a PDF or a text file.
+// Images are kept in Todo.Images instead. Filename is the name the file was
+// uploaded with; the file is stored under Key, which other attachments of
+// the same content share.
+type Attachment struct {
+	Filename    string `gorm:"column:filename;size:255" json:"filename"`
+	Key         string `gorm:"column:key;size:255;index:idx_attachments_file" json:"key"`
+	Size        int64  `gorm:"column:size" json:"size"`
+	ContentType string `gorm:"column:content_type;size:255" json:"content_type"`
+	// Checksum is the hex SHA-256 of the content.
+	Checksum string `gorm:"column:checksum;size:64" json:"checksum"`
+
+	// URL is a signed, expiring URL of the file.
+	URL string `gorm:"-" json:"url,omitempty"`
+
+	TodoID uuid.UUID `gorm:"type:uuid;column:todo_id;index" json:"todo_id"`
+	Todo   *Todo     `gorm:"foreignKey:TodoID;references:ID" json:"todo,omitempty"`
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;references:ID" json:"-"`
+
+	Base
+}
+
+// TodoImagesRequest lists the images of a todo in their new order.
+type TodoImagesRequest struct {
+	Images []string `json:"images" validate:"required"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "github.com/google/uuid"
+
+// CalendarFeed grants read access to a user's iCalendar feed. Only the
+// SHA-256 hash of the secret token is stored.
+type CalendarFeed struct {
+	TokenHash string `gorm:"column:token_hash;size:64;uniqueIndex" json:"-"`
+
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id;uniqueIndex" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;references:ID" json:"-"`
+
+	Base
+}
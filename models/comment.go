@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Comment struct {
+	Body     string         `gorm:"column:body;type:text" json:"body" validate:"required"`
+	Mentions pq.StringArray `gorm:"column:mentions;type:text[]" json:"mentions"`
+
+	TodoID uuid.UUID `gorm:"type:uuid;column:todo_id;index" json:"todo_id"`
+	Todo   *Todo     `gorm:"foreignKey:TodoID;references:ID" json:"todo,omitempty"`
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;references:ID" json:"user"`
+
+	Base
+}
+
+type CommentRequest struct {
+	Body string `json:"body" validate:"required"`
+
+	TodoID uuid.UUID `json:"todo_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type CommentMention struct {
+	CommentID uuid.UUID `json:"comment_id"`
+	TodoID    uuid.UUID `json:"todo_id"`
+	AuthorID  uuid.UUID `json:"author_id"`
+	UserID    uuid.UUID `json:"user_id"`
+}
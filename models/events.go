@@ -0,0 +1,118 @@
+package models
+
+import "github.com/google/uuid"
+
+// Events published on the bus. Each type names its own event type, so it
+// can be used with bus.Publish and bus.Subscribe, and the user it belongs
+// to, so it is only forwarded to that user's webhooks.
+
+// Owned is implemented by events that belong to a user.
+type Owned interface {
+	OwnerID() uuid.UUID
+}
+
+type TodoCreated struct {
+	Todo *Todo `json:"todo"`
+}
+
+func (TodoCreated) EventType() string    { return "todo.created" }
+func (e TodoCreated) OwnerID() uuid.UUID { return e.Todo.UserID }
+
+// TodoChange is published as todo.updated.
+func (TodoChange) EventType() string    { return "todo.updated" }
+func (e TodoChange) OwnerID() uuid.UUID { return e.After.UserID }
+
+type TodoCompleted struct {
+	Todo *Todo `json:"todo"`
+}
+
+func (TodoCompleted) EventType() string    { return "todo.completed" }
+func (e TodoCompleted) OwnerID() uuid.UUID { return e.Todo.UserID }
+
+type TodoReopened struct {
+	Todo *Todo `json:"todo"`
+}
+
+func (TodoReopened) EventType() string    { return "todo.reopened" }
+func (e TodoReopened) OwnerID() uuid.UUID { return e.Todo.UserID }
+
+type TodoArchived struct {
+	Todo *Todo `json:"todo"`
+}
+
+func (TodoArchived) EventType() string    { return "todo.archived" }
+func (e TodoArchived) OwnerID() uuid.UUID { return e.Todo.UserID }
+
+type TodoUnarchived struct {
+	Todo *Todo `json:"todo"`
+}
+
+func (TodoUnarchived) EventType() string    { return "todo.unarchived" }
+func (e TodoUnarchived) OwnerID() uuid.UUID { return e.Todo.UserID }
+
+type TodoMoved struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (TodoMoved) EventType() string    { return "todo.moved" }
+func (e TodoMoved) OwnerID() uuid.UUID { return e.UserID }
+
+type TodoDeleted struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Mode   string    `json:"mode"`
+}
+
+func (TodoDeleted) EventType() string    { return "todo.deleted" }
+func (e TodoDeleted) OwnerID() uuid.UUID { return e.UserID }
+
+// TodoBulkResult is published as todo.bulk.
+func (TodoBulkResult) EventType() string    { return "todo.bulk" }
+func (e TodoBulkResult) OwnerID() uuid.UUID { return e.UserID }
+
+type CommentCreated struct {
+	Comment *Comment `json:"comment"`
+}
+
+func (CommentCreated) EventType() string    { return "comment.created" }
+func (e CommentCreated) OwnerID() uuid.UUID { return e.Comment.UserID }
+
+type CommentUpdated struct {
+	Comment *Comment `json:"comment"`
+}
+
+func (CommentUpdated) EventType() string    { return "comment.updated" }
+func (e CommentUpdated) OwnerID() uuid.UUID { return e.Comment.UserID }
+
+type CommentDeleted struct {
+	ID     uuid.UUID `json:"id"`
+	TodoID uuid.UUID `json:"todo_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (CommentDeleted) EventType() string    { return "comment.deleted" }
+func (e CommentDeleted) OwnerID() uuid.UUID { return e.UserID }
+
+// CommentMention is published as comment.mentioned.
+func (CommentMention) EventType() string    { return "comment.mentioned" }
+func (e CommentMention) OwnerID() uuid.UUID { return e.UserID }
+
+type UserRegistered struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+func (UserRegistered) EventType() string    { return "user.registered" }
+func (e UserRegistered) OwnerID() uuid.UUID { return e.ID }
+
+// ImageUploaded is published for each image stored with a todo or template,
+// which is then processed in the background.
+type ImageUploaded struct {
+	Key    string    `json:"key"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (ImageUploaded) EventType() string    { return "image.uploaded" }
+func (e ImageUploaded) OwnerID() uuid.UUID { return e.UserID }
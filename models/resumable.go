@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResumableUpload is a file uploaded in chunks with the tus protocol. Its
+// data is kept in the tus directory until the last chunk arrives; the file
+// is then moved to the blob store under Key and can be attached to a todo.
+// Uploads that are neither finished nor attached by ExpiresAt are deleted.
+type ResumableUpload struct {
+	Length   int64  `gorm:"column:upload_length" json:"length"`
+	Offset   int64  `gorm:"column:upload_offset" json:"offset"`
+	Filename string `gorm:"column:filename;size:255" json:"filename"`
+
+	// Key, ContentType and Checksum are set once the upload is complete.
+	Key         string     `gorm:"column:key;size:255" json:"-"`
+	ContentType string     `gorm:"column:content_type;size:255" json:"content_type,omitempty"`
+	Checksum    string     `gorm:"column:checksum;size:64" json:"checksum,omitempty"`
+	CompletedAt *time.Time `gorm:"column:completed_at;type:timestamp(6)" json:"completed_at"`
+	ExpiresAt   time.Time  `gorm:"column:expires_at;type:timestamp(6);index" json:"expires_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;references:ID" json:"-"`
+
+	Base
+}
+
+// AttachmentRequest attaches completed resumable uploads to a todo.
+type AttachmentRequest struct {
+	Uploads []uuid.UUID `json:"uploads" validate:"required,min=1"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatsIntervalDay  = "day"
+	StatsIntervalWeek = "week"
+)
+
+// TodoStatsRequest selects the date range of the completion history. From
+// and To are inclusive dates (YYYY-MM-DD).
+type TodoStatsRequest struct {
+	From     string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To       string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Interval string `query:"interval" validate:"omitempty,oneof=day week"`
+
+	UserID uuid.UUID `query:"-"`
+}
+
+type TodoStats struct {
+	Total      int64 `gorm:"column:total" json:"total"`
+	Done       int64 `gorm:"column:done" json:"done"`
+	InProgress int64 `gorm:"column:in_progress" json:"in_progress"`
+	Open       int64 `gorm:"column:open" json:"open"`
+	Archived   int64 `gorm:"column:archived" json:"archived"`
+
+	// AverageCompletionSeconds is the mean time from creation to completion
+	// of the todos completed within the range, nil when there are none.
+	AverageCompletionSeconds *float64 `gorm:"column:average_completion_seconds" json:"average_completion_seconds"`
+
+	From      time.Time         `gorm:"-" json:"from"`
+	To        time.Time         `gorm:"-" json:"to"`
+	Interval  string            `gorm:"-" json:"interval"`
+	Completed []TodoStatsBucket `gorm:"-" json:"completed"`
+}
+
+// TodoStatsBucket counts the todos completed and checklist items checked
+// in the day or week starting at Start.
+type TodoStatsBucket struct {
+	Start time.Time `gorm:"column:start" json:"start"`
+	Todos int64     `gorm:"column:todos" json:"todos"`
+	Items int64     `gorm:"column:items" json:"items"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TodoTemplate is a reusable checklist. Title and items may contain
+// placeholders such as {{date}} that are filled in on instantiation.
+type TodoTemplate struct {
+	Name   string         `gorm:"column:name;size:255" json:"name" validate:"required"`
+	Title  string         `gorm:"column:title;size:255" json:"title" validate:"required"`
+	Todo   pq.StringArray `gorm:"column:todo;type:text[]" json:"todo" validate:"required"`
+	Images pq.StringArray `gorm:"column:images;type:text[]" json:"images"`
+
+	// ImageURLs are signed, expiring URLs of Images, in the same order.
+	ImageURLs []string `gorm:"-" json:"image_urls,omitempty"`
+
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;references:ID" json:"user"`
+
+	Base
+}
+
+type TodoTemplateRequest struct {
+	Name   string   `json:"name" validate:"required"`
+	Title  string   `json:"title" validate:"required"`
+	Todo   []string `json:"todo" validate:"required"`
+	Images []string `json:"images"`
+
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type TemplateFromTodoRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type TemplateInstantiateRequest struct {
+	Variables map[string]string `json:"variables"`
+	ParentID  *uuid.UUID        `json:"parent_id"`
+}
+
+type TodoDuplicateRequest struct {
+	Images     bool `json:"images"`
+	KeepChecks bool `json:"keep_checks"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -10,18 +12,137 @@ type Todo struct {
 	Todo   pq.StringArray `gorm:"column:todo;type:text[]" json:"todo" validate:"required"`
 	Check  pq.StringArray `gorm:"column:check;type:text[]" json:"check" validate:"required"`
 	Images pq.StringArray `gorm:"column:images;type:text[]" json:"images"`
+	DueAt  *time.Time     `gorm:"column:due_at;type:timestamp(6)" json:"due_at"`
+
+	// Images are keys of uploaded images, named by the hex SHA-256 of the
+	// file as uploaded. ImageURLs are signed, expiring URLs of Images, in
+	// the same order.
+	// ImageVariants maps variant names to URLs for each processed image.
+	ImageURLs     []string            `gorm:"-" json:"image_urls,omitempty"`
+	ImageVariants []map[string]string `gorm:"-" json:"image_variants,omitempty"`
 
-	UserID uuid.UUID `gorm:"type:uuid;column:user_id" json:"user_id"`
+	// Status and CompletedAt follow the checklist and are maintained by the
+	// usecase; archiving is independent of them.
+	Status      string     `gorm:"column:status;size:20;default:open;index" json:"status"`
+	CompletedAt *time.Time `gorm:"column:completed_at;type:timestamp(6);index:idx_todos_user_completed,priority:2" json:"completed_at"`
+	ArchivedAt  *time.Time `gorm:"column:archived_at;type:timestamp(6);index" json:"archived_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id;index:idx_todos_user_completed,priority:1" json:"user_id"`
 	User   User      `gorm:"foreignKey:UserID;references:ID" json:"user"`
 
+	ParentID *uuid.UUID `gorm:"type:uuid;column:parent_id;index" json:"parent_id"`
+	Children []*Todo    `gorm:"foreignKey:ParentID;references:ID" json:"children,omitempty"`
+	Position string     `gorm:"column:position;size:255;index" json:"position"`
+
+	Progress *TodoProgress `gorm:"-" json:"progress,omitempty"`
+
+	// Rank and Headline are only selected for full-text searches.
+	Rank     float64 `gorm:"->;-:migration;column:rank" json:"rank,omitempty"`
+	Headline string  `gorm:"->;-:migration;column:headline" json:"headline,omitempty"`
+
 	Base
 }
 
+// TodoProgress counts checklist items of a todo and, in tree views, of all
+// of its descendants.
+type TodoProgress struct {
+	Items   int     `json:"items"`
+	Checked int     `json:"checked"`
+	Percent float64 `json:"percent"`
+}
+
 type TodoRequest struct {
-	Title  string   `json:"title" validate:"required"`
-	Todo   []string `json:"todo" validate:"required"`
-	Check  []string `json:"check"`
-	Images []string `json:"images"`
+	Title  string     `json:"title" validate:"required"`
+	Todo   []string   `json:"todo" validate:"required"`
+	Check  []string   `json:"check"`
+	Images []string   `json:"images"`
+	DueAt  *time.Time `json:"due_at"`
+
+	ParentID *uuid.UUID `json:"parent_id"`
+	UserID   uuid.UUID  `json:"user_id"`
+}
+
+// TodoMoveRequest moves a todo next to the Before and/or After anchors,
+// adopting their parent. Without anchors the todo is moved under ParentID.
+type TodoMoveRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+	Before   *uuid.UUID `json:"before"`
+	After    *uuid.UUID `json:"after"`
+}
+
+const (
+	TodoStatusOpen       = "open"
+	TodoStatusInProgress = "in_progress"
+	TodoStatusDone       = "done"
+)
+
+// Transitions of a todo's status into or out of done.
+const (
+	TransitionCompleted = "completed"
+	TransitionReopened  = "reopened"
+)
+
+const (
+	ArchivedExclude = "false"
+	ArchivedOnly    = "true"
+	ArchivedAll     = "all"
+)
+
+// TodoFilter narrows a todo listing. Search is a full-text query in
+// websearch syntax ("quoted phrases", or, -excluded) unless Prefix is set,
+// in which case every word matches as a prefix. Archived todos are left
+// out unless Archived is "true" (only archived) or "all".
+type TodoFilter struct {
+	Search   string `query:"todo" json:"search"`
+	Language string `query:"lang" json:"lang"`
+	Prefix   bool   `query:"prefix" json:"prefix"`
+	TopLevel bool   `query:"top_level" json:"top_level"`
+	Status   string `query:"status" json:"status" validate:"omitempty,oneof=open in_progress done"`
+	Archived string `query:"archived" json:"archived" validate:"omitempty,oneof=true false all"`
+}
+
+const (
+	DeleteReparent = "reparent"
+	DeleteCascade  = "cascade"
+)
+
+const (
+	BulkCheckAll    = "check_all"
+	BulkUncheckAll  = "uncheck_all"
+	BulkDelete      = "delete"
+	BulkClearImages = "clear_images"
+)
+
+const (
+	BulkStatusOK        = "ok"
+	BulkStatusNotFound  = "not_found"
+	BulkStatusForbidden = "forbidden"
+	BulkStatusFailed    = "failed"
+)
+
+// TodoBulkRequest applies Operations, in order, to the todos listed in IDs
+// or, when IDs is empty, to every todo of the user matching Filter.
+type TodoBulkRequest struct {
+	Operations []string    `json:"operations" validate:"required,min=1,dive,oneof=check_all uncheck_all delete clear_images"`
+	IDs        []uuid.UUID `json:"ids" validate:"max=500"`
+	Filter     *TodoFilter `json:"filter"`
+	DeleteMode string      `json:"delete_mode" validate:"omitempty,oneof=reparent cascade"`
+
+	UserID uuid.UUID `json:"-"`
+}
+
+type TodoBulkItemResult struct {
+	ID         uuid.UUID `json:"id"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Transition string    `json:"transition,omitempty"`
+}
 
-	UserID uuid.UUID `json:"user_id"`
+type TodoBulkResult struct {
+	UserID     uuid.UUID            `json:"user_id"`
+	Operations []string             `json:"operations"`
+	Total      int                  `json:"total"`
+	Succeeded  int                  `json:"succeeded"`
+	Failed     int                  `json:"failed"`
+	Items      []TodoBulkItemResult `json:"items"`
 }
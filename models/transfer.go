@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatICal     = "ics"
+)
+
+// TodoExport is the exported representation of a todo. Imports only read
+// Title, Todo and Check.
+type TodoExport struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Todo        []string   `json:"todo"`
+	Check       []string   `json:"check"`
+	Images      []string   `json:"images"`
+	DueAt       *time.Time `json:"due_at"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type TodoImportRowError struct {
+	Row     int         `json:"row"`
+	Line    int         `json:"line,omitempty"`
+	Message string      `json:"message"`
+	Errors  interface{} `json:"errors,omitempty"`
+}
+
+type TodoImportResult struct {
+	Format   string               `json:"format"`
+	DryRun   bool                 `json:"dry_run"`
+	Total    int                  `json:"total"`
+	Imported int                  `json:"imported"`
+	Failed   int                  `json:"failed"`
+	Errors   []TodoImportRowError `json:"errors"`
+}
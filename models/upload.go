@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Upload is a file a user has stored. Its size counts against the user's
+// storage quota until the file is deleted. Variants holds the keys of the
+// resized copies of an image, set once it has been processed.
+//
+// Files are stored under the hex SHA-256 of their content as uploaded, so
+// a file uploaded again, by the same or another user, is stored once. Each
+// user holding it has an Upload of the key; the file is deleted once
+// nothing references it, along with all of its uploads.
+//
+// AttachedAt is set once a todo, template or attachment references the
+// file. Files that stay unattached, such as those of a failed request, and
+// files no longer referenced are deleted by the upload sweeper.
+type Upload struct {
+	Key         string         `gorm:"column:key;size:255;uniqueIndex:idx_uploads_holder,priority:1" json:"key"`
+	Size        int64          `gorm:"column:size" json:"size"`
+	ContentType string         `gorm:"column:content_type;size:255" json:"content_type"`
+	Variants    pq.StringArray `gorm:"column:variants;type:text[]" json:"variants"`
+	ProcessedAt *time.Time     `gorm:"column:processed_at;type:timestamp(6)" json:"processed_at"`
+	AttachedAt  *time.Time     `gorm:"column:attached_at;type:timestamp(6);index" json:"attached_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id;index;uniqueIndex:idx_uploads_holder,priority:2" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;references:ID" json:"-"`
+
+	Base
+}
+
+// Reconciliation reports what a sweep of the blob store changed, or would
+// change in a dry run.
+type Reconciliation struct {
+	// Orphans are uploads nothing references.
+	Orphans []string `json:"orphans"`
+	// Untracked are stored objects without an upload record and without
+	// references.
+	Untracked []string `json:"untracked"`
+	// Adopted are stored objects without an upload record that something
+	// references; they get a record.
+	Adopted []string `json:"adopted"`
+	// Missing are upload records whose object is gone.
+	Missing []string `json:"missing"`
+	Bytes   int64    `json:"bytes"`
+}
+
+// StorageUsage reports how much of their quota a user has used. A Quota
+// of 0 is unlimited.
+type StorageUsage struct {
+	Files     int64 `json:"files"`
+	Bytes     int64 `json:"bytes"`
+	Quota     int64 `json:"quota"`
+	Remaining int64 `json:"remaining"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Webhook is an endpoint a user has subscribed to their events. Events
+// holds event types or bus patterns such as "todo.*"; empty means all.
+// Failures counts failed attempts in a row, the webhook is disabled once
+// it reaches the limit and enabled again by updating it.
+type Webhook struct {
+	URL        string         `gorm:"column:url;size:2048" json:"url"`
+	Events     pq.StringArray `gorm:"column:events;type:text[]" json:"events"`
+	Secret     string         `gorm:"column:secret;size:128" json:"-"`
+	Active     bool           `gorm:"column:active;default:true" json:"active"`
+	Failures   int            `gorm:"column:failures" json:"failures"`
+	DisabledAt *time.Time     `gorm:"column:disabled_at;type:timestamp(6)" json:"disabled_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;column:user_id;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;references:ID" json:"-"`
+
+	Base
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"dive,required"`
+	// Secret is generated when empty.
+	Secret string `json:"secret" validate:"omitempty,min=16,max=128"`
+	// Active re-enables a disabled webhook.
+	Active *bool `json:"active"`
+
+	UserID uuid.UUID `json:"-"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent to a webhook, with the outcome of its
+// latest attempt. Pending deliveries are attempted at NextAttemptAt.
+type WebhookDelivery struct {
+	EventID       string     `gorm:"column:event_id;size:64" json:"event_id"`
+	EventType     string     `gorm:"column:event_type;size:128" json:"event_type"`
+	Payload       string     `gorm:"column:payload;type:text" json:"payload"`
+	Status        string     `gorm:"column:status;size:20;index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts      int        `gorm:"column:attempts" json:"attempts"`
+	StatusCode    int        `gorm:"column:status_code" json:"status_code"`
+	Response      string     `gorm:"column:response;type:text" json:"response"`
+	Error         string     `gorm:"column:error;type:text" json:"error"`
+	Duration      int64      `gorm:"column:duration_ms" json:"duration_ms"`
+	NextAttemptAt *time.Time `gorm:"column:next_attempt_at;type:timestamp(6);index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at;type:timestamp(6)" json:"delivered_at"`
+
+	// RedeliveryOf is set on manual redeliveries of an earlier delivery.
+	RedeliveryOf *uuid.UUID `gorm:"type:uuid;column:redelivery_of" json:"redelivery_of"`
+
+	WebhookID uuid.UUID `gorm:"type:uuid;column:webhook_id;index" json:"webhook_id"`
+	Webhook   *Webhook  `gorm:"foreignKey:WebhookID;references:ID" json:"-"`
+
+	Base
+}
+
+// WebhookPayload is the body posted to webhooks.
+type WebhookPayload struct {
+	ID            string      `json:"id"`
+	Type          string      `json:"type"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Actor         string      `json:"actor,omitempty"`
+	CorrelationID string      `json:"correlation_id,omitempty"`
+	Data          interface{} `json:"data"`
+}
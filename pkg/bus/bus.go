@@ -1,11 +1,54 @@
 package bus
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrBufferFull is returned by Publish when the event buffer is full and
+	// the backpressure policy does not allow waiting (any longer).
+	ErrBufferFull = errors.New("event buffer is full")
+	// ErrClosed is returned by Publish once the bus has been closed.
+	ErrClosed = errors.New("event bus is closed")
+	// ErrDeadLetterNotFound is returned when replaying an unknown dead letter.
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+)
 
 // Event represents an event in our system
 type Event struct {
-	Type    string
-	Payload interface{}
+	Type     string
+	Payload  interface{}
+	Metadata Metadata
+}
+
+// Metadata describes an occurrence of an event. ID and Timestamp are
+// filled in on publishing when left empty.
+type Metadata struct {
+	ID            string    `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	Actor         string    `json:"actor,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+}
+
+// Stamped returns the event with a new ID and the current time filled in
+// where its metadata lacks them.
+func (e Event) Stamped() Event {
+	if e.Metadata.ID == "" {
+		e.Metadata.ID = uuid.NewString()
+	}
+	if e.Metadata.Timestamp.IsZero() {
+		e.Metadata.Timestamp = time.Now()
+	}
+	return e
 }
 
 // EventHandler is an interface for event handlers
@@ -21,61 +64,502 @@ func (f EventHandlerFunc) Handle(event Event) {
 	f(event)
 }
 
+// ContextHandler is an event handler that honours the per-handler timeout
+// through ctx and reports failures, which are retried. An EventHandler
+// that also implements ContextHandler is called through HandleContext.
+type ContextHandler interface {
+	HandleContext(ctx context.Context, event Event) error
+}
+
+// ContextHandlerFunc is a function type that implements ContextHandler
+type ContextHandlerFunc func(ctx context.Context, event Event) error
+
+// HandleContext calls the function itself
+func (f ContextHandlerFunc) HandleContext(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Backpressure decides what Publish does when the event buffer is full.
+type Backpressure int
+
+const (
+	// BackpressureBlock waits for room in the buffer, up to BlockTimeout.
+	BackpressureBlock Backpressure = iota
+	// BackpressureDrop discards the event.
+	BackpressureDrop
+	// BackpressureError returns ErrBufferFull right away.
+	BackpressureError
+)
+
+// Options configures an EventBus.
+type Options struct {
+	// Workers is the number of goroutines handling events concurrently.
+	Workers int
+	// BufferSize is the number of published events waiting for a worker.
+	BufferSize int
+	// Backpressure is applied when the buffer is full.
+	Backpressure Backpressure
+	// BlockTimeout bounds how long BackpressureBlock waits; 0 waits until
+	// there is room or the bus is closed.
+	BlockTimeout time.Duration
+	// HandlerTimeout bounds a single handler call; 0 means no timeout.
+	HandlerTimeout time.Duration
+	// MaxRetries is the number of times a failed handler is retried before
+	// the event is moved to the dead letters.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles with
+	// every further retry up to MaxBackoff.
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	// MaxDeadLetters bounds the dead letters kept; the oldest are dropped.
+	MaxDeadLetters int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Workers:        4,
+		BufferSize:     100,
+		Backpressure:   BackpressureBlock,
+		BlockTimeout:   5 * time.Second,
+		HandlerTimeout: 30 * time.Second,
+		MaxRetries:     3,
+		RetryBackoff:   100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		MaxDeadLetters: 1000,
+	}
+}
+
+// DeadLetter is an event a handler kept failing on.
+type DeadLetter struct {
+	ID       uint64
+	Event    Event
+	Handler  string
+	Error    string
+	Attempts int
+	FailedAt time.Time
+
+	handler *subscriber
+}
+
+type subscriber struct {
+	pattern string
+	name    string
+	handle  func(ctx context.Context, event Event) error
+	removed atomic.Bool
+}
+
+// Subscription is the handle returned by the Subscribe functions.
+type Subscription struct {
+	bus        *EventBus
+	subscriber *subscriber
+}
+
+// Unsubscribe stops the handler from receiving events, including queued
+// ones it has not been called for yet. It is safe to call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.subscriber.removed.Store(true)
+
+	subscribers := s.bus.subscribers
+	for i, subscriber := range subscribers {
+		if subscriber == s.subscriber {
+			s.bus.subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// delivery is an event waiting for a worker. A nil handlers list means all
+// handlers subscribed to the event type at the time it is handled.
+type delivery struct {
+	event    Event
+	handlers []*subscriber
+}
+
 // EventBus manages the event distribution
 type EventBus struct {
-	eventChannel chan Event
-	handlers     map[string][]EventHandler
+	options Options
+
+	eventChannel chan delivery
+	subscribers  []*subscriber
 	mu           sync.RWMutex
-	wg           sync.WaitGroup
+	wg           sync.WaitGroup // pending deliveries
+	workers      sync.WaitGroup
+
+	closeMu   sync.RWMutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+
+	deadMu      sync.Mutex
+	deadLetters []*DeadLetter
+	lastDeadID  uint64
+
+	dropped atomic.Uint64
 }
 
-// NewEventBus creates a new event bus
+// NewEventBus creates a new event bus with the default options
 func NewEventBus() *EventBus {
+	return NewEventBusWithOptions(DefaultOptions())
+}
+
+// NewEventBusWithOptions creates a new event bus and starts its workers
+func NewEventBusWithOptions(options Options) *EventBus {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.BufferSize < 0 {
+		options.BufferSize = 0
+	}
+
 	bus := &EventBus{
-		eventChannel: make(chan Event, 100), // Buffer size of 100 events
-		handlers:     make(map[string][]EventHandler),
+		options:      options,
+		eventChannel: make(chan delivery, options.BufferSize),
+		done:         make(chan struct{}),
 	}
-	go bus.processEvents()
+
+	bus.workers.Add(options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		go bus.processEvents()
+	}
+
 	return bus
 }
 
-// Subscribe registers a handler for a specific event type
-func (bus *EventBus) Subscribe(eventType string, handler EventHandler) {
+// Subscribe registers a handler for an event type or pattern, see Match
+func (bus *EventBus) Subscribe(pattern string, handler EventHandler) *Subscription {
+	if contextHandler, ok := handler.(ContextHandler); ok {
+		return bus.subscribe(pattern, fmt.Sprintf("%T", handler), contextHandler.HandleContext)
+	}
+
+	return bus.subscribe(pattern, fmt.Sprintf("%T", handler), func(ctx context.Context, event Event) error {
+		handler.Handle(event)
+		return nil
+	})
+}
+
+// SubscribeFunc registers a function as a handler for an event type or pattern
+func (bus *EventBus) SubscribeFunc(pattern string, handlerFunc func(event Event)) *Subscription {
+	return bus.Subscribe(pattern, EventHandlerFunc(handlerFunc))
+}
+
+// SubscribeContext registers a context-aware handler for an event type or pattern
+func (bus *EventBus) SubscribeContext(pattern string, handler ContextHandler) *Subscription {
+	return bus.subscribe(pattern, fmt.Sprintf("%T", handler), handler.HandleContext)
+}
+
+func (bus *EventBus) subscribe(pattern string, name string, handle func(ctx context.Context, event Event) error) *Subscription {
 	bus.mu.Lock()
 	defer bus.mu.Unlock()
-	bus.handlers[eventType] = append(bus.handlers[eventType], handler)
+
+	subscriber := &subscriber{pattern: pattern, name: name, handle: handle}
+	bus.subscribers = append(bus.subscribers, subscriber)
+
+	return &Subscription{bus: bus, subscriber: subscriber}
 }
 
-// SubscribeFunc registers a function as a handler for a specific event type
-func (bus *EventBus) SubscribeFunc(eventType string, handlerFunc func(event Event)) {
-	bus.Subscribe(eventType, EventHandlerFunc(handlerFunc))
+// handlers returns the subscribers whose pattern matches the event type, in
+// subscription order.
+func (bus *EventBus) handlers(eventType string) []*subscriber {
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+
+	var handlers []*subscriber
+	for _, subscriber := range bus.subscribers {
+		if Match(subscriber.pattern, eventType) {
+			handlers = append(handlers, subscriber)
+		}
+	}
+
+	return handlers
 }
 
-// Publish sends an event to the event bus
-func (bus *EventBus) Publish(event Event) {
+// Match reports whether an event type matches a subscription pattern.
+// Patterns are dot-separated like event types; a "*" segment matches any
+// single segment, and a trailing "*" matches one or more. So "todo.*"
+// matches "todo.created", and "*" matches every event.
+func Match(pattern string, eventType string) bool {
+	if pattern == eventType {
+		return true
+	}
+
+	patternSegments := strings.Split(pattern, ".")
+	typeSegments := strings.Split(eventType, ".")
+
+	for i, segment := range patternSegments {
+		if i >= len(typeSegments) {
+			return false
+		}
+		if segment == "*" && i == len(patternSegments)-1 {
+			return true
+		}
+		if segment != "*" && segment != typeSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(typeSegments)
+}
+
+// Publish queues an event for the workers. When the buffer is full the
+// backpressure policy applies: BackpressureBlock waits up to BlockTimeout
+// and then returns ErrBufferFull, BackpressureDrop discards the event and
+// BackpressureError returns ErrBufferFull immediately.
+func (bus *EventBus) Publish(event Event) error {
+	return bus.enqueue(delivery{event: event.Stamped()})
+}
+
+func (bus *EventBus) enqueue(d delivery) error {
+	bus.closeMu.RLock()
+	defer bus.closeMu.RUnlock()
+
+	if bus.closed {
+		return ErrClosed
+	}
+
 	bus.wg.Add(1)
-	bus.eventChannel <- event
+
+	select {
+	case bus.eventChannel <- d:
+		return nil
+	default:
+	}
+
+	switch bus.options.Backpressure {
+	case BackpressureDrop:
+		bus.wg.Done()
+		bus.dropped.Add(1)
+		return nil
+	case BackpressureError:
+		bus.wg.Done()
+		return ErrBufferFull
+	}
+
+	var timeout <-chan time.Time
+	if bus.options.BlockTimeout > 0 {
+		timer := time.NewTimer(bus.options.BlockTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case bus.eventChannel <- d:
+		return nil
+	case <-timeout:
+		bus.wg.Done()
+		return ErrBufferFull
+	case <-bus.done:
+		bus.wg.Done()
+		return ErrClosed
+	}
+}
+
+// Dispatch delivers an event to its handlers synchronously, bypassing the
+// event channel and retries. It returns an error if a handler fails, panics
+// or times out, so callers that need to know the event was handled, such
+// as the outbox relay, can retry.
+func (bus *EventBus) Dispatch(event Event) error {
+	event = event.Stamped()
+
+	var errs []error
+	for _, handler := range bus.handlers(event.Type) {
+		if err := bus.call(handler, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", handler.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
-// processEvents processes events from the event channel
+// processEvents processes events from the event channel until it is closed
 func (bus *EventBus) processEvents() {
-	for event := range bus.eventChannel {
-		bus.mu.RLock()
-		handlers, exists := bus.handlers[event.Type]
-		bus.mu.RUnlock()
-
-		if exists {
-			for _, handler := range handlers {
-				// Create a closure to ensure we use the correct handler and event
-				func(handler EventHandler, event Event) {
-					defer bus.wg.Done()
-					handler.Handle(event)
-				}(handler, event)
+	defer bus.workers.Done()
+
+	for d := range bus.eventChannel {
+		handlers := d.handlers
+		if handlers == nil {
+			handlers = bus.handlers(d.event.Type)
+		}
+
+		for _, handler := range handlers {
+			if !handler.removed.Load() {
+				bus.deliver(handler, d.event)
 			}
-		} else {
-			bus.wg.Done()
 		}
+
+		bus.wg.Done()
+	}
+}
+
+// deliver calls the handler, retrying with exponential backoff, and moves
+// the event to the dead letters if it still fails.
+func (bus *EventBus) deliver(handler *subscriber, event Event) {
+	delay := bus.options.RetryBackoff
+
+	var err error
+	attempts := 0
+	for {
+		attempts++
+		if err = bus.call(handler, event); err == nil {
+			return
+		}
+
+		if attempts > bus.options.MaxRetries {
+			break
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-bus.done:
+			// do not hold up shutdown with retries
+			bus.addDeadLetter(handler, event, err, attempts)
+			return
+		}
+
+		delay *= 2
+		if bus.options.MaxBackoff > 0 && delay > bus.options.MaxBackoff {
+			delay = bus.options.MaxBackoff
+		}
+	}
+
+	bus.addDeadLetter(handler, event, err, attempts)
+}
+
+// call runs a single handler with panic recovery and the handler timeout.
+// A handler that ignores its context keeps running in the background once
+// it times out, but no longer holds up the worker.
+func (bus *EventBus) call(handler *subscriber, event Event) error {
+	if bus.options.HandlerTimeout <= 0 {
+		return safeCall(context.Background(), handler, event)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bus.options.HandlerTimeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- safeCall(ctx, handler, event)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func safeCall(ctx context.Context, handler *subscriber, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler for %s panicked: %v", event.Type, r)
+		}
+	}()
+
+	return handler.handle(ctx, event)
+}
+
+func (bus *EventBus) addDeadLetter(handler *subscriber, event Event, err error, attempts int) {
+	bus.deadMu.Lock()
+	defer bus.deadMu.Unlock()
+
+	bus.lastDeadID++
+	bus.deadLetters = append(bus.deadLetters, &DeadLetter{
+		ID:       bus.lastDeadID,
+		Event:    event,
+		Handler:  handler.name,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+		handler:  handler,
+	})
+
+	if max := bus.options.MaxDeadLetters; max > 0 && len(bus.deadLetters) > max {
+		bus.deadLetters = append([]*DeadLetter(nil), bus.deadLetters[len(bus.deadLetters)-max:]...)
+	}
+}
+
+// DeadLetters returns the events handlers kept failing on, oldest first.
+func (bus *EventBus) DeadLetters() []DeadLetter {
+	bus.deadMu.Lock()
+	defer bus.deadMu.Unlock()
+
+	letters := make([]DeadLetter, len(bus.deadLetters))
+	for i, letter := range bus.deadLetters {
+		letters[i] = *letter
+	}
+
+	return letters
+}
+
+// Replay queues a dead letter again for the handler that failed on it and
+// removes it from the dead letters. If the handler fails again it becomes
+// a new dead letter.
+func (bus *EventBus) Replay(id uint64) error {
+	letter := bus.takeDeadLetter(id)
+	if letter == nil {
+		return ErrDeadLetterNotFound
+	}
+
+	err := bus.enqueue(delivery{event: letter.Event, handlers: []*subscriber{letter.handler}})
+	if err != nil {
+		bus.restoreDeadLetter(letter)
 	}
+
+	return err
+}
+
+// ReplayAll replays every dead letter and returns how many were queued.
+func (bus *EventBus) ReplayAll() (int, error) {
+	replayed := 0
+	for _, letter := range bus.DeadLetters() {
+		if err := bus.Replay(letter.ID); err != nil && !errors.Is(err, ErrDeadLetterNotFound) {
+			return replayed, err
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+// Discard removes a dead letter without replaying it.
+func (bus *EventBus) Discard(id uint64) error {
+	if bus.takeDeadLetter(id) == nil {
+		return ErrDeadLetterNotFound
+	}
+
+	return nil
+}
+
+func (bus *EventBus) takeDeadLetter(id uint64) *DeadLetter {
+	bus.deadMu.Lock()
+	defer bus.deadMu.Unlock()
+
+	for i, letter := range bus.deadLetters {
+		if letter.ID == id {
+			bus.deadLetters = append(bus.deadLetters[:i], bus.deadLetters[i+1:]...)
+			return letter
+		}
+	}
+
+	return nil
+}
+
+func (bus *EventBus) restoreDeadLetter(letter *DeadLetter) {
+	bus.deadMu.Lock()
+	defer bus.deadMu.Unlock()
+
+	i := sort.Search(len(bus.deadLetters), func(i int) bool {
+		return bus.deadLetters[i].ID > letter.ID
+	})
+	bus.deadLetters = append(bus.deadLetters[:i], append([]*DeadLetter{letter}, bus.deadLetters[i:]...)...)
+}
+
+// Dropped returns the number of events discarded by BackpressureDrop.
+func (bus *EventBus) Dropped() uint64 {
+	return bus.dropped.Load()
 }
 
 // Wait waits for all published events to be processed
@@ -83,7 +567,18 @@ func (bus *EventBus) Wait() {
 	bus.wg.Wait()
 }
 
-// Close shuts down the event bus
+// Close stops accepting events and waits for the workers to handle the
+// events already queued. Publishers waiting for room get ErrClosed.
 func (bus *EventBus) Close() {
-	close(bus.eventChannel)
+	bus.closeOnce.Do(func() {
+		close(bus.done)
+
+		bus.closeMu.Lock()
+		bus.closed = true
+		bus.closeMu.Unlock()
+
+		close(bus.eventChannel)
+	})
+
+	bus.workers.Wait()
 }
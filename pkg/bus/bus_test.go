@@ -1,6 +1,13 @@
 package bus
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 type testHandler struct {
 	called bool
@@ -27,3 +34,217 @@ func TestEventBus(t *testing.T) {
 
 	t.Log("EventBus test passed")
 }
+
+func TestEventBusDispatch(t *testing.T) {
+	bus := NewEventBus()
+
+	handler := &testHandler{}
+	bus.Subscribe("test", handler)
+
+	if err := bus.Dispatch(Event{Type: "test"}); err != nil {
+		t.Fatalf("Dispatch returned %v", err)
+	}
+	if !handler.called {
+		t.Errorf("Handler was not called")
+	}
+
+	bus.SubscribeFunc("panic", func(event Event) {
+		panic("boom")
+	})
+	if err := bus.Dispatch(Event{Type: "panic"}); err == nil {
+		t.Errorf("Dispatch did not report the panicking handler")
+	}
+}
+
+func testOptions() Options {
+	options := DefaultOptions()
+	options.RetryBackoff = time.Millisecond
+	options.MaxBackoff = time.Millisecond
+	return options
+}
+
+func TestEventBusMultipleHandlers(t *testing.T) {
+	bus := NewEventBusWithOptions(testOptions())
+	defer bus.Close()
+
+	var calls atomic.Int32
+	for i := 0; i < 3; i++ {
+		bus.SubscribeFunc("test", func(event Event) {
+			calls.Add(1)
+		})
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := bus.Publish(Event{Type: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bus.Wait()
+
+	if got := calls.Load(); got != 30 {
+		t.Errorf("handlers called %d times, want 30", got)
+	}
+}
+
+func TestEventBusWorkers(t *testing.T) {
+	options := testOptions()
+	options.Workers = 2
+	bus := NewEventBusWithOptions(options)
+	defer bus.Close()
+
+	// both events can only finish if they are handled concurrently
+	var started sync.WaitGroup
+	started.Add(2)
+	bus.SubscribeFunc("test", func(event Event) {
+		started.Done()
+		started.Wait()
+	})
+
+	bus.Publish(Event{Type: "test"})
+	bus.Publish(Event{Type: "test"})
+
+	done := make(chan struct{})
+	go func() {
+		bus.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("events were not handled concurrently")
+	}
+}
+
+func TestEventBusRetries(t *testing.T) {
+	bus := NewEventBusWithOptions(testOptions())
+	defer bus.Close()
+
+	var calls atomic.Int32
+	bus.SubscribeContext("test", ContextHandlerFunc(func(ctx context.Context, event Event) error {
+		if calls.Add(1) < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}))
+
+	bus.Publish(Event{Type: "test"})
+	bus.Wait()
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("handler called %d times, want 3", got)
+	}
+	if letters := bus.DeadLetters(); len(letters) != 0 {
+		t.Errorf("got %d dead letters, want none", len(letters))
+	}
+}
+
+func TestEventBusDeadLetters(t *testing.T) {
+	bus := NewEventBusWithOptions(testOptions())
+	defer bus.Close()
+
+	var fail atomic.Bool
+	fail.Store(true)
+	var calls atomic.Int32
+	bus.SubscribeFunc("test", func(event Event) {
+		calls.Add(1)
+		if fail.Load() {
+			panic("boom")
+		}
+	})
+
+	bus.Publish(Event{Type: "test", Payload: "payload"})
+	bus.Wait()
+
+	letters := bus.DeadLetters()
+	if len(letters) != 1 {
+		t.Fatalf("got %d dead letters, want 1", len(letters))
+	}
+	if letters[0].Attempts != 4 || letters[0].Event.Payload != "payload" {
+		t.Errorf("dead letter = %+v, want 4 attempts with the event payload", letters[0])
+	}
+
+	fail.Store(false)
+	if err := bus.Replay(letters[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	bus.Wait()
+
+	if got := calls.Load(); got != 5 {
+		t.Errorf("handler called %d times, want 5", got)
+	}
+	if letters := bus.DeadLetters(); len(letters) != 0 {
+		t.Errorf("got %d dead letters after replay, want none", len(letters))
+	}
+	if err := bus.Replay(letters[0].ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("second replay returned %v, want ErrDeadLetterNotFound", err)
+	}
+}
+
+func TestEventBusHandlerTimeout(t *testing.T) {
+	options := testOptions()
+	options.HandlerTimeout = 10 * time.Millisecond
+	options.MaxRetries = 0
+	bus := NewEventBusWithOptions(options)
+	defer bus.Close()
+
+	bus.SubscribeContext("test", ContextHandlerFunc(func(ctx context.Context, event Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	bus.Publish(Event{Type: "test"})
+	bus.Wait()
+
+	letters := bus.DeadLetters()
+	if len(letters) != 1 || letters[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("dead letters = %+v, want one deadline exceeded", letters)
+	}
+}
+
+func TestEventBusBackpressure(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		backpressure Backpressure
+		want         error
+	}{
+		{"block", BackpressureBlock, ErrBufferFull},
+		{"drop", BackpressureDrop, nil},
+		{"error", BackpressureError, ErrBufferFull},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			options := testOptions()
+			options.Workers = 1
+			options.BufferSize = 1
+			options.Backpressure = test.backpressure
+			options.BlockTimeout = 10 * time.Millisecond
+			bus := NewEventBusWithOptions(options)
+
+			release := make(chan struct{})
+			bus.SubscribeFunc("test", func(event Event) {
+				<-release
+			})
+
+			// one event held by the worker, one filling the buffer
+			bus.Publish(Event{Type: "test"})
+			for len(bus.eventChannel) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+			bus.Publish(Event{Type: "test"})
+
+			if err := bus.Publish(Event{Type: "test"}); err != test.want {
+				t.Errorf("Publish returned %v, want %v", err, test.want)
+			}
+			if test.backpressure == BackpressureDrop && bus.Dropped() != 1 {
+				t.Errorf("Dropped() = %d, want 1", bus.Dropped())
+			}
+
+			close(release)
+			bus.Close()
+
+			if err := bus.Publish(Event{Type: "test"}); err != ErrClosed {
+				t.Errorf("Publish after Close returned %v, want ErrClosed", err)
+			}
+		})
+	}
+}
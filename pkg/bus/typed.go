@@ -0,0 +1,118 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+)
+
+// Typed is implemented by event payloads that know their event type, so
+// they can be published and subscribed to without spelling out the name.
+type Typed interface {
+	EventType() string
+}
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	correlationKey
+)
+
+// WithActor returns a context whose events are attributed to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// WithCorrelationID returns a context whose events carry the correlation
+// ID, e.g. of the request that caused them.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey, id)
+}
+
+// NewEvent wraps a typed payload in an Event, taking the actor and
+// correlation ID from ctx.
+func NewEvent(ctx context.Context, payload Typed) Event {
+	actor, _ := ctx.Value(actorKey).(string)
+	correlationID, _ := ctx.Value(correlationKey).(string)
+
+	return Event{
+		Type:    payload.EventType(),
+		Payload: payload,
+		Metadata: Metadata{
+			Actor:         actor,
+			CorrelationID: correlationID,
+		},
+	}.Stamped()
+}
+
+// Publish publishes a typed payload under its own event type.
+func Publish[T Typed](bus *EventBus, ctx context.Context, payload T) error {
+	return bus.Publish(NewEvent(ctx, payload))
+}
+
+// Subscribe registers fn for the events of type T. Payloads published as T
+// or *T are passed to fn; any other payload under the same event type is
+// reported as a handler error.
+func Subscribe[T Typed](bus *EventBus, fn func(ctx context.Context, payload T, metadata Metadata) error) *Subscription {
+	var zero T
+
+	return bus.subscribe(zero.EventType(), fmt.Sprintf("bus.Subscribe[%T]", zero), func(ctx context.Context, event Event) error {
+		payload, ok := payloadAs[T](event.Payload)
+		if !ok {
+			return fmt.Errorf("event %s has payload %T, want %T", event.Type, event.Payload, zero)
+		}
+
+		return fn(ctx, payload, event.Metadata)
+	})
+}
+
+func payloadAs[T any](payload interface{}) (T, bool) {
+	switch p := payload.(type) {
+	case T:
+		return p, true
+	case *T:
+		if p != nil {
+			return *p, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// Registration subscribes a handler when passed to EventBus.Register, so a
+// module can declare all of its subscribers in one place.
+type Registration func(bus *EventBus) *Subscription
+
+// On declares a typed subscription, see Subscribe.
+func On[T Typed](fn func(ctx context.Context, payload T, metadata Metadata) error) Registration {
+	return func(bus *EventBus) *Subscription {
+		return Subscribe(bus, fn)
+	}
+}
+
+// OnPattern declares a subscription of handler to an event type or pattern.
+func OnPattern(pattern string, handler EventHandler) Registration {
+	return func(bus *EventBus) *Subscription {
+		return bus.Subscribe(pattern, handler)
+	}
+}
+
+// OnContext declares a subscription of a context-aware handler to an event
+// type or pattern.
+func OnContext(pattern string, handler ContextHandler) Registration {
+	return func(bus *EventBus) *Subscription {
+		return bus.SubscribeContext(pattern, handler)
+	}
+}
+
+// Register subscribes every declared handler and returns the subscriptions
+// in the same order.
+func (bus *EventBus) Register(registrations ...Registration) []*Subscription {
+	subscriptions := make([]*Subscription, len(registrations))
+	for i, register := range registrations {
+		subscriptions[i] = register(bus)
+	}
+
+	return subscriptions
+}
@@ -0,0 +1,170 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type itemCreated struct {
+	Name string
+}
+
+func (itemCreated) EventType() string {
+	return "item.created"
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventType string
+		want      bool
+	}{
+		{"item.created", "item.created", true},
+		{"item.created", "item.deleted", false},
+		{"item.*", "item.created", true},
+		{"item.*", "item.created.twice", true},
+		{"item.*", "item", false},
+		{"item.*", "other.created", false},
+		{"*.created", "item.created", true},
+		{"*.created", "item.deleted", false},
+		{"*", "item.created", true},
+	}
+
+	for _, test := range tests {
+		if got := Match(test.pattern, test.eventType); got != test.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", test.pattern, test.eventType, got, test.want)
+		}
+	}
+}
+
+func TestTypedSubscribe(t *testing.T) {
+	bus := NewEventBusWithOptions(testOptions())
+	defer bus.Close()
+
+	var mu sync.Mutex
+	var names []string
+	var metadata []Metadata
+	Subscribe(bus, func(ctx context.Context, event itemCreated, meta Metadata) error {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, event.Name)
+		metadata = append(metadata, meta)
+		return nil
+	})
+
+	ctx := WithCorrelationID(WithActor(context.Background(), "alice"), "request-1")
+	if err := Publish(bus, ctx, itemCreated{Name: "value"}); err != nil {
+		t.Fatal(err)
+	}
+	// the string API and pointer payloads reach typed handlers as well
+	bus.Publish(Event{Type: "item.created", Payload: &itemCreated{Name: "pointer"}})
+	bus.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(names) != 2 || names[0] != "value" || names[1] != "pointer" {
+		t.Fatalf("handler got %v, want [value pointer]", names)
+	}
+	if metadata[0].Actor != "alice" || metadata[0].CorrelationID != "request-1" {
+		t.Errorf("metadata = %+v, want actor alice and correlation ID request-1", metadata[0])
+	}
+	for _, meta := range metadata {
+		if meta.ID == "" || meta.Timestamp.IsZero() {
+			t.Errorf("metadata = %+v, want an ID and timestamp", meta)
+		}
+	}
+	if metadata[0].ID == metadata[1].ID {
+		t.Errorf("events share the ID %s", metadata[0].ID)
+	}
+}
+
+func TestTypedSubscribeWrongPayload(t *testing.T) {
+	options := testOptions()
+	options.MaxRetries = 0
+	bus := NewEventBusWithOptions(options)
+	defer bus.Close()
+
+	Subscribe(bus, func(ctx context.Context, event itemCreated, meta Metadata) error {
+		return nil
+	})
+
+	bus.Publish(Event{Type: "item.created", Payload: "not an item"})
+	bus.Wait()
+
+	if letters := bus.DeadLetters(); len(letters) != 1 {
+		t.Errorf("got %d dead letters, want 1", len(letters))
+	}
+}
+
+func TestWildcardAndUnsubscribe(t *testing.T) {
+	bus := NewEventBusWithOptions(testOptions())
+	defer bus.Close()
+
+	var mu sync.Mutex
+	seen := map[string][]string{}
+	record := func(name string) func(event Event) {
+		return func(event Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[name] = append(seen[name], event.Type)
+		}
+	}
+
+	bus.SubscribeFunc("item.*", record("items"))
+	all := bus.SubscribeFunc("*", record("all"))
+
+	bus.Publish(Event{Type: "item.created"})
+	bus.Publish(Event{Type: "user.created"})
+	bus.Wait()
+
+	all.Unsubscribe()
+	all.Unsubscribe()
+
+	bus.Publish(Event{Type: "item.deleted"})
+	bus.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if got := seen["items"]; len(got) != 2 || got[0] != "item.created" || got[1] != "item.deleted" {
+		t.Errorf("item.* got %v, want [item.created item.deleted]", got)
+	}
+	if got := seen["all"]; len(got) != 2 {
+		t.Errorf("* got %v, want the two events before unsubscribing", got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	bus := NewEventBusWithOptions(testOptions())
+	defer bus.Close()
+
+	var mu sync.Mutex
+	var got []string
+	subscriptions := bus.Register(
+		On(func(ctx context.Context, event itemCreated, meta Metadata) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, "typed:"+event.Name)
+			return nil
+		}),
+		OnPattern("item.*", EventHandlerFunc(func(event Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, "pattern:"+event.Type)
+		})),
+	)
+	if len(subscriptions) != 2 {
+		t.Fatalf("Register returned %d subscriptions, want 2", len(subscriptions))
+	}
+
+	Publish(bus, context.Background(), itemCreated{Name: "value"})
+	bus.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got[0] != "typed:value" || got[1] != "pattern:item.created" {
+		t.Errorf("handlers got %v, want [typed:value pattern:item.created]", got)
+	}
+}
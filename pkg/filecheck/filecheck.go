@@ -0,0 +1,58 @@
+// Package filecheck checks files sent by clients before they are stored.
+package filecheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// CleanFilename drops any directories from a client's file name, so it is
+// safe to show and to offer as a download name.
+func CleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return "file"
+	}
+	if len(name) > 255 {
+		// keep the extension
+		name = strings.ToValidUTF8(name[len(name)-255:], "")
+	}
+
+	return name
+}
+
+// Inspect detects the type of the content and computes its hex SHA-256 in
+// one pass, reading r to the end.
+func Inspect(r io.Reader) (*mimetype.MIME, string, error) {
+	hash := sha256.New()
+	detected, err := mimetype.DetectReader(io.TeeReader(r, hash))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(hash, r); err != nil {
+		return nil, "", err
+	}
+
+	return detected, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// AllowedType reports whether detected is one of the allowed types or
+// their aliases. An empty list allows any type.
+func AllowedType(detected *mimetype.MIME, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, t := range allowed {
+		if detected.Is(strings.TrimSpace(t)) {
+			return true
+		}
+	}
+
+	return false
+}
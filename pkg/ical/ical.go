@@ -0,0 +1,286 @@
+// Package ical reads and writes the subset of iCalendar (RFC 5545) needed
+// to exchange tasks and events: components, properties with parameters,
+// line folding and text escaping.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateTimeFormat    = "20060102T150405Z"
+	localTimeFormat   = "20060102T150405"
+	dateFormat        = "20060102"
+	maxLineOctets     = 75
+	ProductIdentifier = "-//practice//todo//EN"
+)
+
+var ErrInvalidCalendar = errors.New("ical: invalid calendar")
+
+// Property is a single content line such as "DUE;VALUE=DATE:20240101".
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block, e.g. VTODO, with its properties and
+// nested components.
+type Component struct {
+	Name       string
+	Properties []Property
+	Children   []*Component
+}
+
+// Get returns the first property with the given name.
+func (c *Component) Get(name string) (Property, bool) {
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+	return Property{}, false
+}
+
+// Text returns the unescaped value of a text property.
+func (c *Component) Text(name string) string {
+	prop, ok := c.Get(name)
+	if !ok {
+		return ""
+	}
+	return Unescape(prop.Value)
+}
+
+// Time parses a DATE or DATE-TIME property. Floating times and TZID
+// parameters are interpreted in loc.
+func (c *Component) Time(name string, loc *time.Location) (*time.Time, error) {
+	prop, ok := c.Get(name)
+	if !ok {
+		return nil, nil
+	}
+
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+
+	var t time.Time
+	var err error
+	switch {
+	case strings.HasSuffix(prop.Value, "Z"):
+		t, err = time.Parse(dateTimeFormat, prop.Value)
+	case len(prop.Value) == len(dateFormat):
+		t, err = time.ParseInLocation(dateFormat, prop.Value, loc)
+	default:
+		t, err = time.ParseInLocation(localTimeFormat, prop.Value, loc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidCalendar, name, err)
+	}
+
+	return &t, nil
+}
+
+// Find returns all nested components with the given names, depth first.
+func (c *Component) Find(names ...string) []*Component {
+	var found []*Component
+	for _, child := range c.Children {
+		for _, name := range names {
+			if child.Name == name {
+				found = append(found, child)
+				break
+			}
+		}
+		found = append(found, child.Find(names...)...)
+	}
+	return found
+}
+
+// Parse reads the first VCALENDAR from r.
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+	var root *Component
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, component)
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrInvalidCalendar, prop.Value)
+			}
+			root = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				if root.Name != "VCALENDAR" {
+					return nil, fmt.Errorf("%w: missing VCALENDAR", ErrInvalidCalendar)
+				}
+				return root, nil
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: property outside of a component", ErrInvalidCalendar)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: missing END:VCALENDAR", ErrInvalidCalendar)
+}
+
+// unfold joins continuation lines (starting with a space or tab) and drops
+// empty lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	return lines, nil
+}
+
+func parseLine(line string) (Property, error) {
+	// the value starts at the first colon that is not inside a quoted
+	// parameter value
+	quoted, colon := false, -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return Property{}, fmt.Errorf("%w: malformed line %q", ErrInvalidCalendar, line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := Property{
+		Name:   strings.ToUpper(parts[0]),
+		Params: map[string]string{},
+		Value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return prop, nil
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// Escape escapes a TEXT value.
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// Unescape reverses Escape.
+func Unescape(text string) string {
+	return unescaper.Replace(text)
+}
+
+// FormatTime formats t as a UTC DATE-TIME value.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+// Writer writes content lines, folding them at 75 octets with CRLF line
+// endings.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Property writes "NAME:value". The value must already be escaped.
+func (w *Writer) Property(name, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text writes a TEXT property, escaping its value.
+func (w *Writer) Text(name, value string) {
+	w.Property(name, Escape(value))
+}
+
+// Time writes a UTC DATE-TIME property.
+func (w *Writer) Time(name string, t time.Time) {
+	w.Property(name, FormatTime(t))
+}
+
+func (w *Writer) Begin(name string) {
+	w.Property("BEGIN", name)
+}
+
+func (w *Writer) End(name string) {
+	w.Property("END", name)
+}
+
+// Err returns the first write error.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+func (w *Writer) line(line string) {
+	if w.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	_, w.err = io.WriteString(w.w, b.String())
+}
@@ -0,0 +1,107 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAndParse(t *testing.T) {
+	due := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	summary := "Release checklist, v2; " + strings.Repeat("long ", 30)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Begin("VCALENDAR")
+	w.Property("VERSION", "2.0")
+	w.Begin("VTODO")
+	w.Property("UID", "1@practice")
+	w.Text("SUMMARY", summary)
+	w.Text("DESCRIPTION", "[x] build\n[ ] ship")
+	w.Time("DUE", due)
+	w.End("VTODO")
+	w.End("VCALENDAR")
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line longer than %d octets: %q", maxLineOctets, line)
+		}
+	}
+
+	calendar, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos := calendar.Find("VTODO")
+	if len(todos) != 1 {
+		t.Fatalf("found %d VTODO components, want 1", len(todos))
+	}
+
+	if got := todos[0].Text("SUMMARY"); got != summary {
+		t.Errorf("SUMMARY = %q, want %q", got, summary)
+	}
+	if got := todos[0].Text("DESCRIPTION"); got != "[x] build\n[ ] ship" {
+		t.Errorf("DESCRIPTION = %q", got)
+	}
+
+	got, err := todos[0].Time("DUE", time.UTC)
+	if err != nil || got == nil || !got.Equal(due) {
+		t.Errorf("DUE = %v, %v, want %v", got, err, due)
+	}
+}
+
+func TestParseDatesAndParams(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY;LANGUAGE=\"en:US\":Standup\r\n" +
+		"DTSTART;TZID=Asia/Jakarta:20240102T080000\r\n" +
+		"DUE;VALUE=DATE:20240103\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	calendar, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := calendar.Find("VEVENT")[0]
+	if got := event.Text("SUMMARY"); got != "Standup" {
+		t.Errorf("SUMMARY = %q, want Standup", got)
+	}
+
+	start, err := event.Time("DTSTART", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("DTSTART = %v, want %v", start, want)
+	}
+
+	day, err := event.Time("DUE", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC); !day.Equal(want) {
+		t.Errorf("DUE = %v, want %v", day, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+		"SUMMARY:orphan\r\n",
+		"BEGIN:VCALENDAR\r\nnot a property\r\nEND:VCALENDAR\r\n",
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
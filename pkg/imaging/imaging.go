@@ -0,0 +1,203 @@
+// Package imaging prepares uploaded photos for display: it turns them
+// upright, drops their metadata and renders smaller variants.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	// registers the WebP decoder with image.Decode
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image too large")
+)
+
+// MaxPixels bounds the images decoded, so a small file declaring huge
+// dimensions cannot exhaust memory.
+const MaxPixels = 50_000_000
+
+const jpegQuality = 85
+
+// Variant is a size an image is rendered at. The image is scaled down to
+// fit within Width x Height, keeping its aspect ratio; it is never scaled
+// up.
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// ParseVariants parses a list such as "thumb:200x200,medium:1024x1024".
+func ParseVariants(spec string) ([]Variant, error) {
+	var variants []Variant
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, size, ok := strings.Cut(item, ":")
+		width, height, ok2 := strings.Cut(size, "x")
+		if !ok || !ok2 || name == "" || strings.ContainsAny(name, "/.") {
+			return nil, fmt.Errorf("invalid image variant %q", item)
+		}
+
+		w, err := strconv.Atoi(width)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid image variant %q", item)
+		}
+		h, err := strconv.Atoi(height)
+		if err != nil || h <= 0 {
+			return nil, fmt.Errorf("invalid image variant %q", item)
+		}
+
+		variants = append(variants, Variant{Name: name, Width: w, Height: h})
+	}
+
+	return variants, nil
+}
+
+// Decode decodes a JPEG, PNG or WebP image and returns it upright
+// according to its EXIF orientation, together with its format name.
+func Decode(data []byte) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", ErrUnsupported
+		}
+		return nil, "", err
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return Orient(img, Orientation(data)), format, nil
+}
+
+// Fit scales img down to fit within width x height.
+func Fit(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= width && h <= height {
+		return img
+	}
+
+	if w*height > h*width {
+		h = max(h*width/w, 1)
+		w = width
+	} else {
+		w = max(w*height/h, 1)
+		h = height
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
+
+// OutputFormat returns the format an image decoded from format is encoded
+// in. There is no WebP encoder, so those become PNG when they have
+// transparency and JPEG otherwise.
+func OutputFormat(img image.Image, format string) string {
+	switch format {
+	case "jpeg", "png":
+		return format
+	}
+
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return "jpeg"
+	}
+	return "png"
+}
+
+// Encode writes img in format, "jpeg" or "png", without any metadata.
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case "png":
+		return png.Encode(w, img)
+	default:
+		return ErrUnsupported
+	}
+}
+
+// Rendition is an encoded image.
+type Rendition struct {
+	Name   string
+	Format string
+	Data   []byte
+}
+
+// Process decodes a photo and returns it cleaned of metadata and turned
+// upright, followed by its variants. JPEG photos without metadata are
+// returned as they are.
+//
+// WebP photos cannot be encoded again, so upright ones only have their
+// metadata chunks removed. Those that need turning are encoded in the
+// format of their variants instead, see OutputFormat.
+func Process(data []byte, variants []Variant) (*Rendition, []Rendition, error) {
+	img, format, err := Decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := OutputFormat(img, format)
+	original := &Rendition{Format: format}
+	switch {
+	case format == "webp" && Orientation(data) == 1:
+		original.Data, err = StripWebP(data)
+	case format == "jpeg" && !hasJPEGMetadata(data):
+		// spare clean photos another lossy encoding
+		original.Data = data
+	default:
+		var buf bytes.Buffer
+		original.Format = output
+		err = Encode(&buf, img, output)
+		original.Data = buf.Bytes()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	renditions := make([]Rendition, len(variants))
+	for i, variant := range variants {
+		var buf bytes.Buffer
+		if err := Encode(&buf, Fit(img, variant.Width, variant.Height), output); err != nil {
+			return nil, nil, err
+		}
+		renditions[i] = Rendition{Name: variant.Name, Format: output, Data: buf.Bytes()}
+	}
+
+	return original, renditions, nil
+}
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Extension returns the file extension of a format.
+func Extension(format string) string {
+	if format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + format
+}
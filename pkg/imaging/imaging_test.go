@@ -0,0 +1,228 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestParseVariants(t *testing.T) {
+	variants, err := ParseVariants("thumb:200x150, medium:1024x1024,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 2 || variants[0] != (Variant{"thumb", 200, 150}) || variants[1] != (Variant{"medium", 1024, 1024}) {
+		t.Errorf("ParseVariants = %+v", variants)
+	}
+
+	for _, spec := range []string{"thumb", "thumb:200", "thumb:0x10", "../x:10x10", ":10x10"} {
+		if _, err := ParseVariants(spec); err == nil {
+			t.Errorf("ParseVariants(%q) succeeded", spec)
+		}
+	}
+}
+
+// exifJPEG returns a w x h JPEG whose left half is red, with an EXIF
+// orientation.
+func exifJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.NRGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.NRGBA{0, 0, 255, 255})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	// big-endian TIFF with one IFD entry: orientation, SHORT, count 1
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	tiff = binary.BigEndian.AppendUint16(tiff, orientationTag)
+	tiff = append(tiff, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		if got := Orientation(exifJPEG(t, 4, 2, orientation)); got != int(orientation) {
+			t.Errorf("Orientation = %d, want %d", got, orientation)
+		}
+	}
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)), nil)
+	if got := Orientation(buf.Bytes()); got != 1 {
+		t.Errorf("Orientation without EXIF = %d, want 1", got)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// a 2x1 image, red then blue
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		want        [][]color.NRGBA
+	}{
+		{1, [][]color.NRGBA{{red, blue}}},
+		{2, [][]color.NRGBA{{blue, red}}},
+		{3, [][]color.NRGBA{{blue, red}}},
+		{4, [][]color.NRGBA{{red, blue}}},
+		{5, [][]color.NRGBA{{red}, {blue}}},
+		{6, [][]color.NRGBA{{red}, {blue}}},
+		{7, [][]color.NRGBA{{blue}, {red}}},
+		{8, [][]color.NRGBA{{blue}, {red}}},
+	}
+
+	for _, test := range tests {
+		oriented := Orient(img, test.orientation)
+		bounds := oriented.Bounds()
+		if bounds.Dy() != len(test.want) || bounds.Dx() != len(test.want[0]) {
+			t.Errorf("Orient(%d) size = %v", test.orientation, bounds.Size())
+			continue
+		}
+		for y, row := range test.want {
+			for x, want := range row {
+				if got := color.NRGBAModel.Convert(oriented.At(bounds.Min.X+x, bounds.Min.Y+y)); got != want {
+					t.Errorf("Orient(%d) at %d,%d = %v, want %v", test.orientation, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 100))
+
+	if size := Fit(img, 200, 200).Bounds().Size(); size != image.Pt(200, 50) {
+		t.Errorf("Fit = %v, want 200x50", size)
+	}
+	if size := Fit(img, 1000, 1000).Bounds().Size(); size != image.Pt(400, 100) {
+		t.Errorf("Fit scaled up to %v", size)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	data := exifJPEG(t, 40, 20, 6)
+
+	original, variants, err := Process(data, []Variant{{"thumb", 10, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Orientation(original.Data) != 1 || bytes.Contains(original.Data, []byte("Exif")) {
+		t.Error("original still has its EXIF data")
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(original.Data))
+	if err != nil || format != "jpeg" || config.Width != 20 || config.Height != 40 {
+		t.Errorf("original = %s %dx%d, %v, want upright jpeg", format, config.Width, config.Height, err)
+	}
+
+	if len(variants) != 1 || variants[0].Name != "thumb" || variants[0].Format != "jpeg" {
+		t.Fatalf("variants = %+v", variants)
+	}
+	config, _, err = image.DecodeConfig(bytes.NewReader(variants[0].Data))
+	if err != nil || config.Width != 5 || config.Height != 10 {
+		t.Errorf("thumb = %dx%d, %v, want 5x10", config.Width, config.Height, err)
+	}
+
+	var clean bytes.Buffer
+	jpeg.Encode(&clean, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+	original, _, err = Process(clean.Bytes(), nil)
+	if err != nil || !bytes.Equal(original.Data, clean.Bytes()) {
+		t.Errorf("clean JPEG was encoded again: %v", err)
+	}
+
+	if _, _, err := Process([]byte("not an image"), nil); err != ErrUnsupported {
+		t.Errorf("Process of text = %v, want ErrUnsupported", err)
+	}
+}
+
+// chunk encodes a RIFF chunk of a WebP file.
+func chunk(fourCC string, payload []byte) []byte {
+	out := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+	out = append(out, payload...)
+	if len(payload)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// webpExif is an EXIF chunk payload with orientation 6.
+var webpExif = append([]byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01"), 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0x00, 0x00)
+
+func TestStripWebP(t *testing.T) {
+	exif := webpExif
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{webpFlagEXIF | webpFlagXMP | 0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+	body = append(body, chunk("VP8L", []byte{1, 2, 3})...)
+	body = append(body, chunk("EXIF", exif)...)
+	body = append(body, chunk("XMP ", []byte("<x/>"))...)
+	data := append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+
+	if Orientation(data) != 6 {
+		t.Errorf("Orientation = %d, want 6", Orientation(data))
+	}
+
+	stripped, err := StripWebP(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(4+18+12))...)
+	want = append(want, "WEBP"...)
+	want = append(want, chunk("VP8X", []byte{0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+	want = append(want, chunk("VP8L", []byte{1, 2, 3})...)
+	if !bytes.Equal(stripped, want) {
+		t.Errorf("StripWebP =\n%q\nwant\n%q", stripped, want)
+	}
+}
+
+func TestProcessWebP(t *testing.T) {
+	// a 1x1 lossless image
+	pixel := []byte("\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+	webp := func(chunks ...[]byte) []byte {
+		body := []byte("WEBP")
+		for _, c := range chunks {
+			body = append(body, c...)
+		}
+		return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+	}
+
+	upright := webp(chunk("VP8L", pixel))
+	original, _, err := Process(upright, nil)
+	if err != nil || original.Format != "webp" || !bytes.Equal(original.Data, upright) {
+		t.Errorf("upright WebP = %s, %v, want it unchanged", original.Format, err)
+	}
+
+	turned := webp(chunk("VP8X", []byte{webpFlagEXIF, 0, 0, 0, 0, 0, 0, 0, 0, 0}), chunk("VP8L", pixel), chunk("EXIF", webpExif))
+	original, _, err = Process(turned, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(original.Data))
+	if err != nil || format == "webp" || format != original.Format || Orientation(original.Data) != 1 {
+		t.Errorf("turned WebP = %s encoded as %s, %v, want it encoded again upright", format, original.Format, err)
+	}
+}
@@ -0,0 +1,71 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errInvalidWebP = errors.New("invalid WebP file")
+
+// VP8X flags announcing metadata chunks.
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// StripWebP removes the EXIF and XMP chunks of a WebP file, leaving the
+// image data as it is.
+func StripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidWebP
+	}
+
+	out := append([]byte(nil), data[:12]...)
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errInvalidWebP
+		}
+
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+size > len(data) {
+			return nil, errInvalidWebP
+		}
+		// chunks are padded to an even size, except maybe the last one
+		end := min(i+8+size+size%2, len(data))
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+
+	return out, nil
+}
+
+// webpChunk returns the payload of the first chunk with the FourCC.
+func webpChunk(data []byte, fourCC string) []byte {
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+size > len(data) {
+			return nil
+		}
+		if string(data[i:i+4]) == fourCC {
+			return data[i+8 : i+8+size]
+		}
+		i += 8 + size + size%2
+	}
+
+	return nil
+}
@@ -1,12 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"practice/env"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func JWTAuth() fiber.Handler {
@@ -18,30 +20,67 @@ func JWTAuth() fiber.Handler {
 			})
 		}
 
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+		return authenticate(c, strings.TrimPrefix(authHeader, "Bearer "))
+	}
+}
 
-		token, err := jwt.ParseWithClaims(tokenStr, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return []byte(fmt.Sprint(env.JWTSecretKey)), nil
-		})
+// StreamAuth is JWTAuth for streaming endpoints. Browsers cannot set
+// headers on WebSocket and EventSource requests, so the token may also be
+// passed in the access_token query parameter.
+func StreamAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			return authenticate(c, strings.TrimPrefix(authHeader, "Bearer "))
+		}
 
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "invalid token",
-			})
+		if token := c.Query("access_token"); token != "" {
+			return authenticate(c, token)
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "invalid token",
-			})
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+}
+
+func authenticate(c *fiber.Ctx, tokenStr string) error {
+	token, err := jwt.ParseWithClaims(tokenStr, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
 		}
+		return []byte(fmt.Sprint(env.JWTSecretKey)), nil
+	})
 
-		c.Locals("user", claims)
+	if err != nil || !token.Valid {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "invalid token",
+		})
+	}
 
-		return c.Next()
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "invalid token",
+		})
 	}
+
+	c.Locals("user", claims)
+
+	return c.Next()
+}
+
+// UserID returns the ID of the user authenticated by JWTAuth or StreamAuth.
+func UserID(c *fiber.Ctx) (uuid.UUID, error) {
+	claims, ok := c.Locals("user").(jwt.MapClaims)
+	if !ok {
+		return uuid.Nil, errors.New("missing user claims")
+	}
+
+	id, ok := claims["id"].(string)
+	if !ok {
+		return uuid.Nil, errors.New("missing user id claim")
+	}
+
+	return uuid.Parse(id)
 }
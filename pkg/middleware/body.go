@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BufferBody reads streamed request bodies into memory for handlers that
+// use c.Body() or c.MultipartForm(). With StreamRequestBody the server no
+// longer refuses bodies over its BodyLimit, so this refuses those over
+// limit instead. Requests for which stream returns true keep their body
+// stream, see c.Request().BodyStream().
+func BufferBody(limit int, stream func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		if !req.IsBodyStream() || stream(c) {
+			return c.Next()
+		}
+
+		if req.Header.ContentLength() > limit {
+			// the body is left unread
+			c.Context().SetConnectionClose()
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"message": "request too large",
+				"limit":   limit,
+			})
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "failed to read request",
+			})
+		}
+		if len(body) > limit {
+			c.Context().SetConnectionClose()
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"message": "request too large",
+				"limit":   limit,
+			})
+		}
+
+		req.SetBody(body)
+		return c.Next()
+	}
+}
+
+// StreamBody serves routes whose handler reads the body stream itself. The
+// request gets timeout to finish sending its body, replacing the
+// ReadTimeout of the server. A failed request may leave its body unread, so
+// its connection is closed rather than reused.
+func StreamBody(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if conn := c.Context().Conn(); conn != nil {
+			conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+
+		err := c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			c.Context().SetConnectionClose()
+		}
+		return err
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"practice/pkg/logger"
+	"practice/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorStatus is the HTTP status a usecase error is answered with.
+type ErrorStatus struct {
+	Err    error
+	Status int
+}
+
+// Fail answers err with the status of the first of statuses it matches,
+// with 400 and the invalid fields for validation errors, and with 500
+// otherwise. Only the last are logged as errors.
+func Fail(c *fiber.Ctx, logger *logger.Logger, err error, statuses []ErrorStatus) error {
+	var fieldErrors validator.FieldErrors
+	var validationError *validator.ValidationError
+	switch {
+	case errors.As(err, &fieldErrors):
+		logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+			"errors":  fieldErrors,
+		})
+	case errors.As(err, &validationError):
+		logger.Debug(err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid request",
+			"errors":  validationError.Errors,
+		})
+	}
+
+	for _, status := range statuses {
+		if errors.Is(err, status.Err) {
+			logger.Debug(err.Error())
+			return c.Status(status.Status).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
+
+	logger.Error(err.Error())
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": "internal server error",
+	})
+}
@@ -1,46 +1,169 @@
 package middleware
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
+	"context"
+	"errors"
+	"mime/multipart"
+	"practice/pkg/filecheck"
+	"practice/pkg/storage"
 
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/utils"
 )
 
-func Upload() fiber.Handler {
+// UploadLimits restricts the files accepted by Upload. Zero sizes and
+// counts are unlimited; an empty AllowedTypes accepts any type.
+type UploadLimits struct {
+	MaxFileSize    int64
+	MaxRequestSize int64
+	MaxFiles       int
+	AllowedTypes   []string
+}
+
+// UploadedFile describes a file stored by UploadFiles.
+type UploadedFile struct {
+	// Key is the Checksum followed by the extension of the type.
+	Key string
+	// Filename is the base name the client sent, for display only.
+	Filename    string
+	Size        int64
+	ContentType string
+	// Checksum is the hex SHA-256 of the content.
+	Checksum string
+}
+
+// Upload stores the multipart "images" files, see UploadFiles.
+func Upload(blob storage.Blob, limits UploadLimits) fiber.Handler {
+	return UploadFiles(blob, "images", limits)
+}
+
+// UploadFiles stores the multipart files of field in blob under the hex
+// SHA-256 of their content and passes the keys on in the "filenames" local
+// and their descriptions in the "uploads" local. Files blob has already are
+// not stored again, see storage.PutOnce.
+//
+// Every file is checked before any is stored. The type is detected from
+// the content, the client's content type and extension are ignored. Files
+// count against the quota of the user authenticated by JWTAuth, if any.
+func UploadFiles(blob storage.Blob, field string, limits UploadLimits) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		form, err := c.MultipartForm()
-		fmt.Println("form", form.File)
-		if err != nil || form == nil || form.File == nil || form.File["images"] == nil {
+		if err != nil || form == nil || form.File == nil || form.File[field] == nil {
 			c.Locals("filenames", []string{})
+			c.Locals("uploads", []UploadedFile{})
 			return c.Next()
 		}
 
-		files := form.File["images"]
-		uploadDir := "uploads"
-		if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-			if err := os.Mkdir(uploadDir, 0755); err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": "failed to create upload directory",
+		files := form.File[field]
+		if limits.MaxFiles > 0 && len(files) > limits.MaxFiles {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"message": "too many files",
+				"limit":   limits.MaxFiles,
+			})
+		}
+
+		var total int64
+		types := make([]*mimetype.MIME, len(files))
+		checksums := make([]string, len(files))
+		for i, file := range files {
+			if limits.MaxFileSize > 0 && file.Size > limits.MaxFileSize {
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+					"message": "file too large",
+					"file":    file.Filename,
+					"limit":   limits.MaxFileSize,
+				})
+			}
+
+			total += file.Size
+			if limits.MaxRequestSize > 0 && total > limits.MaxRequestSize {
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+					"message": "request too large",
+					"limit":   limits.MaxRequestSize,
+				})
+			}
+
+			types[i], checksums[i], err = inspect(file)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "failed to read file",
+					"file":    file.Filename,
+				})
+			}
+
+			if !filecheck.AllowedType(types[i], limits.AllowedTypes) {
+				return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+					"message": "unsupported file type",
+					"file":    file.Filename,
+					"type":    types[i].String(),
 				})
 			}
 		}
 
+		var ctx context.Context = c.Context()
+		if userID, err := UserID(c); err == nil {
+			ctx = storage.WithOwner(ctx, userID.String())
+		}
+
 		var filenames []string
-		for _, file := range files {
-			filename := utils.UUIDv4() + filepath.Ext(file.Filename)
-			filePath := filepath.Join(uploadDir, filename)
-			if err := c.SaveFile(file, filePath); err != nil {
+		var uploads []UploadedFile
+		// stored are the files this request added to blob, the only ones to
+		// delete again on failure
+		var stored []string
+		for i, file := range files {
+			filename := checksums[i] + types[i].Extension()
+
+			err := func() error {
+				src, err := file.Open()
+				if err != nil {
+					return err
+				}
+				defer src.Close()
+
+				added, err := storage.PutOnce(ctx, blob, filename, src, storage.PutOptions{
+					ContentType: types[i].String(),
+				})
+				if added {
+					stored = append(stored, filename)
+				}
+				return err
+			}()
+			if err != nil {
+				for _, key := range stored {
+					blob.Delete(ctx, key)
+				}
+				if errors.Is(err, storage.ErrQuotaExceeded) {
+					return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"message": "failed to save file",
 				})
 			}
 			filenames = append(filenames, filename)
+			uploads = append(uploads, UploadedFile{
+				Key:         filename,
+				Filename:    filecheck.CleanFilename(file.Filename),
+				Size:        file.Size,
+				ContentType: types[i].String(),
+				Checksum:    checksums[i],
+			})
 		}
 
 		c.Locals("filenames", filenames)
+		c.Locals("uploads", uploads)
 		return c.Next()
 	}
 }
+
+// inspect detects the type of the file and computes its hex SHA-256 in
+// one pass.
+func inspect(file *multipart.FileHeader) (*mimetype.MIME, string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer src.Close()
+
+	return filecheck.Inspect(src)
+}
@@ -0,0 +1,59 @@
+// Package outbox stores bus events in the database, in the same transaction
+// as the change they describe, and relays them to the event bus afterwards.
+// Events survive crashes and restarts, are delivered at least once and, for
+// a given aggregate, in the order they were stored.
+package outbox
+
+import (
+	"encoding/json"
+	"practice/pkg/bus"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Message is a stored event. Pending messages have neither DeliveredAt nor
+// FailedAt set.
+type Message struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement;column:id;index:idx_outbox_events_pending,where:delivered_at IS NULL AND failed_at IS NULL"`
+	AggregateID   string     `gorm:"column:aggregate_id;size:64;index"`
+	Type          string     `gorm:"column:type;size:128"`
+	Payload       string     `gorm:"column:payload;type:text"`
+	EventID       string     `gorm:"column:event_id;size:64"`
+	Actor         string     `gorm:"column:actor;size:64"`
+	CorrelationID string     `gorm:"column:correlation_id;size:128"`
+	OccurredAt    time.Time  `gorm:"column:occurred_at;type:timestamp(6)"`
+	Attempts      int        `gorm:"column:attempts"`
+	LastError     string     `gorm:"column:last_error;type:text"`
+	AvailableAt   time.Time  `gorm:"column:available_at;type:timestamp(6)"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at;type:timestamp(6);index"`
+	FailedAt      *time.Time `gorm:"column:failed_at;type:timestamp(6)"`
+	CreatedAt     time.Time  `gorm:"column:created_at;type:timestamp(6);autoCreateTime"`
+}
+
+func (Message) TableName() string {
+	return "outbox_events"
+}
+
+// Store appends an event for the given aggregate to the outbox. db should
+// be the transaction that writes the change the event describes, so both
+// are committed or rolled back together.
+func Store(db *gorm.DB, aggregateID string, event bus.Event) error {
+	event = event.Stamped()
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&Message{
+		AggregateID:   aggregateID,
+		Type:          event.Type,
+		Payload:       string(payload),
+		EventID:       event.Metadata.ID,
+		Actor:         event.Metadata.Actor,
+		CorrelationID: event.Metadata.CorrelationID,
+		OccurredAt:    event.Metadata.Timestamp,
+		AvailableAt:   time.Now(),
+	}).Error
+}
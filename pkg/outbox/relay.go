@@ -0,0 +1,290 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"practice/pkg/bus"
+	"practice/pkg/logger"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// relayLockKey is the Postgres advisory lock that lets a single relay
+// dispatch at a time when several app instances share the database.
+const relayLockKey = 0x6f7574626f78
+
+type Options struct {
+	// PollInterval is how often pending messages are looked up.
+	PollInterval time.Duration
+	// BatchSize is the number of messages claimed at a time.
+	BatchSize int
+	// ClaimTimeout is how long a relay has to dispatch the messages it
+	// claimed before another relay may claim them.
+	ClaimTimeout time.Duration
+	// MaxAttempts is the number of failed dispatches after which a message
+	// is marked failed and no longer retried.
+	MaxAttempts int
+	// MaxBackoff caps the exponential delay between retries.
+	MaxBackoff time.Duration
+	// Retention is how long delivered messages are kept before cleanup.
+	Retention time.Duration
+	// CleanupInterval is how often delivered messages are cleaned up.
+	CleanupInterval time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		PollInterval:    time.Second,
+		BatchSize:       100,
+		ClaimTimeout:    5 * time.Minute,
+		MaxAttempts:     10,
+		MaxBackoff:      5 * time.Minute,
+		Retention:       24 * time.Hour,
+		CleanupInterval: time.Hour,
+	}
+}
+
+// Relay dispatches stored messages to the event bus.
+type Relay struct {
+	db      *gorm.DB
+	bus     *bus.EventBus
+	logger  *logger.Logger
+	options Options
+
+	mu       sync.RWMutex
+	payloads map[string]reflect.Type
+}
+
+func NewRelay(db *gorm.DB, bus *bus.EventBus, logger *logger.Logger, options Options) *Relay {
+	return &Relay{
+		db:       db,
+		bus:      bus,
+		logger:   logger,
+		options:  options,
+		payloads: make(map[string]reflect.Type),
+	}
+}
+
+// Register sets the types stored payloads are decoded into, by their event
+// type. Handlers receive a pointer to the type, e.g. *models.TodoCreated
+// for Register(models.TodoCreated{}). Payloads of unregistered event types
+// are passed on as json.RawMessage.
+func (r *Relay) Register(payloads ...bus.Typed) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, payload := range payloads {
+		t := reflect.TypeOf(payload)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		r.payloads[payload.EventType()] = t
+	}
+}
+
+// Run relays messages until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	poll := time.NewTicker(r.options.PollInterval)
+	defer poll.Stop()
+
+	cleanup := time.NewTicker(r.options.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			for {
+				dispatched, err := r.Flush(ctx)
+				if err != nil {
+					r.logger.Error("outbox relay failed", "error", err)
+					break
+				}
+				if dispatched < r.options.BatchSize {
+					break
+				}
+			}
+		case <-cleanup.C:
+			if err := r.Cleanup(ctx); err != nil {
+				r.logger.Error("outbox cleanup failed", "error", err)
+			}
+		}
+	}
+}
+
+// Flush dispatches one batch of pending messages and returns how many were
+// looked at. The batch is claimed for ClaimTimeout in a short transaction
+// and dispatched after it commits, so slow handlers hold up neither the
+// relay lock nor other relays. A message is only marked delivered after its
+// handlers have returned, so a crash in between delivers it again once the
+// claim runs out. Once a message of an aggregate fails, later messages of
+// that aggregate wait for its retry.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	messages, claimedUntil, err := r.claim(ctx)
+	if err != nil || len(messages) == 0 {
+		return len(messages), err
+	}
+
+	blocked := make(map[string]bool)
+	var delivered, released []uint64
+	for _, message := range messages {
+		// messages left when the claim runs out may be claimed again
+		if blocked[message.AggregateID] || !time.Now().Before(claimedUntil) {
+			released = append(released, message.ID)
+			continue
+		}
+
+		if err := r.dispatch(message); err != nil {
+			blocked[message.AggregateID] = true
+			if err := r.fail(r.db.WithContext(ctx), message, err); err != nil {
+				return len(messages), err
+			}
+			continue
+		}
+
+		delivered = append(delivered, message.ID)
+	}
+
+	if len(delivered) > 0 {
+		err := r.db.WithContext(ctx).Model(&Message{}).
+			Where("id IN ?", delivered).
+			Update("delivered_at", time.Now()).Error
+		if err != nil {
+			return len(messages), err
+		}
+	}
+
+	if len(released) > 0 {
+		err := r.db.WithContext(ctx).Model(&Message{}).
+			Where("id IN ? AND delivered_at IS NULL AND failed_at IS NULL AND available_at = ?", released, claimedUntil).
+			Update("available_at", time.Now()).Error
+		if err != nil {
+			return len(messages), err
+		}
+	}
+
+	return len(messages), nil
+}
+
+// claim looks up a batch of pending messages and makes them unavailable
+// until the returned time, while holding the relay lock. Claimed messages
+// also hold back later messages of their aggregates.
+func (r *Relay) claim(ctx context.Context) ([]*Message, time.Time, error) {
+	var messages []*Message
+	claimedUntil := time.Now().Add(r.options.ClaimTimeout).Truncate(time.Microsecond)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", relayLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		// skip aggregates whose earliest pending message is still backing off
+		// or claimed
+		err := tx.Raw(`
+			SELECT * FROM outbox_events m
+			WHERE m.delivered_at IS NULL AND m.failed_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events p
+				WHERE p.aggregate_id = m.aggregate_id AND p.id <= m.id
+				AND p.delivered_at IS NULL AND p.failed_at IS NULL AND p.available_at > ?
+			)
+			ORDER BY m.id
+			LIMIT ?`, time.Now(), r.options.BatchSize).Scan(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]uint64, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		return tx.Model(&Message{}).Where("id IN ?", ids).Update("available_at", claimedUntil).Error
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return messages, claimedUntil, nil
+}
+
+func (r *Relay) dispatch(message *Message) error {
+	payload, err := r.decode(message)
+	if err != nil {
+		return err
+	}
+
+	return r.bus.Dispatch(bus.Event{
+		Type:    message.Type,
+		Payload: payload,
+		Metadata: bus.Metadata{
+			ID:            message.EventID,
+			Timestamp:     message.OccurredAt,
+			Actor:         message.Actor,
+			CorrelationID: message.CorrelationID,
+		},
+	})
+}
+
+func (r *Relay) decode(message *Message) (interface{}, error) {
+	r.mu.RLock()
+	t, ok := r.payloads[message.Type]
+	r.mu.RUnlock()
+
+	if !ok {
+		return json.RawMessage(message.Payload), nil
+	}
+
+	payload := reflect.New(t).Interface()
+	if err := json.Unmarshal([]byte(message.Payload), payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// fail records a failed dispatch and schedules the retry, or gives up on
+// the message after MaxAttempts, which unblocks its aggregate.
+func (r *Relay) fail(tx *gorm.DB, message *Message, err error) error {
+	attempts := message.Attempts + 1
+	r.logger.Error("outbox dispatch failed", "id", message.ID, "type", message.Type, "attempts", attempts, "error", err)
+
+	updates := map[string]interface{}{
+		"attempts":     attempts,
+		"last_error":   err.Error(),
+		"available_at": time.Now().Add(backoff(attempts, r.options.MaxBackoff)),
+	}
+	if attempts >= r.options.MaxAttempts {
+		updates["failed_at"] = time.Now()
+	}
+
+	return tx.Model(&Message{}).Where("id = ?", message.ID).Updates(updates).Error
+}
+
+// Cleanup deletes messages delivered longer than Retention ago.
+func (r *Relay) Cleanup(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Where("delivered_at < ?", time.Now().Add(-r.options.Retention)).
+		Delete(&Message{}).Error
+}
+
+// backoff doubles the retry delay with every attempt, starting at one
+// second, up to max.
+func backoff(attempts int, max time.Duration) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,59 @@
+package outbox
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type testPayload struct {
+	Title string `json:"title"`
+}
+
+func (testPayload) EventType() string {
+	return "test.registered"
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{20, time.Minute},
+	}
+
+	for _, test := range tests {
+		if got := backoff(test.attempts, time.Minute); got != test.want {
+			t.Errorf("backoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	relay := NewRelay(nil, nil, nil, DefaultOptions())
+	relay.Register(testPayload{})
+
+	payload, err := relay.decode(&Message{Type: "test.registered", Payload: `{"title":"hello"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, ok := payload.(*testPayload)
+	if !ok || decoded.Title != "hello" {
+		t.Errorf("decode = %#v, want *testPayload with title hello", payload)
+	}
+
+	payload, err = relay.decode(&Message{Type: "test.unknown", Payload: `{"title":"hello"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := payload.(json.RawMessage); !ok {
+		t.Errorf("decode of unregistered type = %T, want json.RawMessage", payload)
+	}
+
+	if _, err := relay.decode(&Message{Type: "test.registered", Payload: `{`}); err == nil {
+		t.Errorf("decode of invalid payload succeeded")
+	}
+}
@@ -0,0 +1,75 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	cases := []struct {
+		a, b string
+	}{
+		{"", ""},
+		{"", "V"},
+		{"V", ""},
+		{"V", "W"},
+		{"V", "V1"},
+		{"Vz", "W"},
+		{"0001", "0002"},
+	}
+
+	for _, tc := range cases {
+		key, err := Between(tc.a, tc.b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q) returned error: %v", tc.a, tc.b, err)
+		}
+		if key <= tc.a || (tc.b != "" && key >= tc.b) {
+			t.Errorf("Between(%q, %q) = %q, not in range", tc.a, tc.b, key)
+		}
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	cases := []struct {
+		a, b string
+	}{
+		{"W", "V"},
+		{"V", "V"},
+		{"V0", ""},
+		{"V-", ""},
+	}
+
+	for _, tc := range cases {
+		if _, err := Between(tc.a, tc.b); err != ErrInvalidRange {
+			t.Errorf("Between(%q, %q) error = %v, want ErrInvalidRange", tc.a, tc.b, err)
+		}
+	}
+}
+
+func TestBetweenRandomInserts(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	keys := []string{}
+
+	for i := 0; i < 1000; i++ {
+		idx := r.Intn(len(keys) + 1)
+		a, b := "", ""
+		if idx > 0 {
+			a = keys[idx-1]
+		}
+		if idx < len(keys) {
+			b = keys[idx]
+		}
+
+		key, err := Between(a, b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q) returned error: %v", a, b, err)
+		}
+
+		keys = append(keys[:idx], append([]string{key}, keys[idx:]...)...)
+	}
+
+	if !sort.StringsAreSorted(keys) {
+		t.Error("keys are not sorted after random inserts")
+	}
+}
@@ -0,0 +1,39 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languages are the text search configurations shipped with PostgreSQL.
+var languages = map[string]bool{
+	"simple": true, "arabic": true, "armenian": true, "basque": true,
+	"catalan": true, "danish": true, "dutch": true, "english": true,
+	"finnish": true, "french": true, "german": true, "greek": true,
+	"hindi": true, "hungarian": true, "indonesian": true, "irish": true,
+	"italian": true, "lithuanian": true, "nepali": true, "norwegian": true,
+	"portuguese": true, "romanian": true, "russian": true, "serbian": true,
+	"spanish": true, "swedish": true, "tamil": true, "turkish": true,
+	"yiddish": true,
+}
+
+// IsLanguage reports whether lang is a built-in text search configuration.
+func IsLanguage(lang string) bool {
+	return languages[lang]
+}
+
+// PrefixQuery turns free text into a to_tsquery expression that matches
+// every word as a prefix, e.g. "rel chec" becomes "rel:* & chec:*". It
+// returns an empty string when the text contains no words.
+func PrefixQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = strings.ToLower(word) + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,30 @@
+package search
+
+import "testing"
+
+func TestPrefixQuery(t *testing.T) {
+	cases := map[string]string{
+		"rel chec":           "rel:* & chec:*",
+		"  Release!  ":       "release:*",
+		"a'b | c & !d:*":     "a:* & b:* & c:* & d:*",
+		"onboarding-2024":    "onboarding:* & 2024:*",
+		"":                   "",
+		"&|!()":              "",
+		"pembaruan aplikasi": "pembaruan:* & aplikasi:*",
+	}
+
+	for text, want := range cases {
+		if got := PrefixQuery(text); got != want {
+			t.Errorf("PrefixQuery(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestIsLanguage(t *testing.T) {
+	if !IsLanguage("english") || !IsLanguage("indonesian") {
+		t.Error("expected built-in configurations to be accepted")
+	}
+	if IsLanguage("english'; DROP TABLE todos; --") {
+		t.Error("expected unknown configuration to be rejected")
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocal(filepath.Join(dir, "uploads"), "http://localhost:8080/api/files/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "variants/a/thumb.png", strings.NewReader("hello world"), PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "uploads", "variants", "a", "thumb.png")); err != nil {
+		t.Errorf("object not stored below the root: %v", err)
+	}
+
+	info, err := store.Stat(ctx, "variants/a/thumb.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 11 || info.ContentType != "image/png" {
+		t.Errorf("Stat = %+v", info)
+	}
+
+	r, err := store.GetRange(ctx, "variants/a/thumb.png", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(r); string(body) != "wor" {
+		t.Errorf("GetRange = %q, want wor", body)
+	}
+	r.Close()
+
+	if err := Copy(ctx, store, "variants/a/thumb.png", "copy.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "variants/a/thumb.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "variants/a/thumb.png"); err != nil {
+		t.Errorf("Delete of missing object = %v", err)
+	}
+	if _, err := store.Get(ctx, "variants/a/thumb.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Stat(ctx, "copy.png"); err != nil {
+		t.Errorf("Stat of copy = %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../outside", `a\b`} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), PutOptions{}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestLocalList(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocal(dir, "http://localhost:8080/api/files/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"a.jpg", "variants/a/thumb.jpg", "b.png"} {
+		if err := store.Put(ctx, key, strings.NewReader("hello"), PutOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// left behind by an interrupted Put
+	if err := os.WriteFile(filepath.Join(dir, ".upload-123"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".tus"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".tus", "partial"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	err = store.List(ctx, "", func(info Info) error {
+		if info.Size != 5 {
+			t.Errorf("List info = %+v", info)
+		}
+		keys = append(keys, info.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "a.jpg,b.png,variants/a/thumb.jpg" {
+		t.Errorf("List = %v", keys)
+	}
+
+	keys = nil
+	store.List(ctx, "variants/", func(info Info) error {
+		keys = append(keys, info.Key)
+		return nil
+	})
+	if strings.Join(keys, ",") != "variants/a/thumb.jpg" {
+		t.Errorf("List with prefix = %v", keys)
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	store, _ := NewLocal(t.TempDir(), "http://localhost:8080/api/files/", "secret")
+
+	signed, err := store.SignedURL(context.Background(), "a b.png", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/api/files/a b.png" || u.Query().Get("signature") == "" || u.Query().Get("expires") == "" {
+		t.Errorf("SignedURL = %s", signed)
+	}
+
+	query := u.Query()
+	expiresAt, err := store.Verify("a b.png", query.Get("expires"), query.Get("signature"), time.Now())
+	if err != nil {
+		t.Fatalf("Verify = %v", err)
+	}
+	if until := time.Until(expiresAt); until <= 0 || until > time.Hour {
+		t.Errorf("Verify expiry = %v", expiresAt)
+	}
+
+	if _, err := store.Verify("other.png", query.Get("expires"), query.Get("signature"), time.Now()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of other key = %v, want ErrInvalidSignature", err)
+	}
+	if _, err := store.Verify("a b.png", "1", query.Get("signature"), time.Now()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of altered expiry = %v, want ErrInvalidSignature", err)
+	}
+	if _, err := store.Verify("a b.png", query.Get("expires"), query.Get("signature"), time.Now().Add(2*time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify after expiry = %v, want ErrExpired", err)
+	}
+}
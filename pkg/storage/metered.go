@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+type ownerKey struct{}
+
+// WithOwner returns a context whose uploads are counted against owner.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// OwnerFrom returns the owner set by WithOwner, or "".
+func OwnerFrom(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
+
+// Ledger records the stored objects of each owner. An object may have
+// several owners, each holding it once.
+type Ledger interface {
+	// UploadedBytes returns the total size of the owner's objects.
+	UploadedBytes(ctx context.Context, owner string) (int64, error)
+	// HasUpload reports whether the owner holds the object.
+	HasUpload(ctx context.Context, owner string, key string) (bool, error)
+	// AddUpload records that the owner holds an object, replacing the
+	// owner's record of the same key.
+	AddUpload(ctx context.Context, owner string, info Info) error
+	// RemoveUpload removes the records of every owner of the object.
+	RemoveUpload(ctx context.Context, key string) error
+}
+
+// Metered records objects put or linked with an owner in the ledger and
+// limits each owner to quota bytes. An object counts fully against each of
+// its owners. The quota is checked without locking, so parallel uploads may
+// overshoot it by their size.
+type Metered struct {
+	Blob
+	ledger Ledger
+	quota  int64
+}
+
+// NewMetered wraps blob. A quota of 0 is unlimited.
+func NewMetered(blob Blob, ledger Ledger, quota int64) *Metered {
+	return &Metered{
+		Blob:   blob,
+		ledger: ledger,
+		quota:  quota,
+	}
+}
+
+// Put fails with ErrQuotaExceeded as soon as the content would exceed the
+// owner's quota. Objects put without an owner are not recorded.
+func (m *Metered) Put(ctx context.Context, key string, r io.Reader, options PutOptions) error {
+	owner := OwnerFrom(ctx)
+	if owner == "" {
+		return m.Blob.Put(ctx, key, r, options)
+	}
+
+	counter := &countingReader{r: r, limit: -1}
+	if m.quota > 0 {
+		used, err := m.ledger.UploadedBytes(ctx, owner)
+		if err != nil {
+			return err
+		}
+		if used >= m.quota {
+			return ErrQuotaExceeded
+		}
+		counter.limit = m.quota - used
+	}
+
+	if err := m.Blob.Put(ctx, key, counter, options); err != nil {
+		if counter.exceeded {
+			return ErrQuotaExceeded
+		}
+		return err
+	}
+
+	err := m.ledger.AddUpload(ctx, owner, Info{
+		Key:         key,
+		Size:        counter.n,
+		ContentType: options.ContentType,
+		ModTime:     time.Now(),
+	})
+	if err != nil {
+		m.Blob.Delete(ctx, key)
+		return err
+	}
+
+	return nil
+}
+
+// Link fails with ErrQuotaExceeded if the object would exceed the owner's
+// quota. Linking an object the owner holds already changes nothing.
+func (m *Metered) Link(ctx context.Context, key string) error {
+	info, err := m.Blob.Stat(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	owner := OwnerFrom(ctx)
+	if owner == "" {
+		return nil
+	}
+
+	held, err := m.ledger.HasUpload(ctx, owner, key)
+	if err != nil || held {
+		return err
+	}
+
+	if m.quota > 0 {
+		used, err := m.ledger.UploadedBytes(ctx, owner)
+		if err != nil {
+			return err
+		}
+		if used+info.Size > m.quota {
+			return ErrQuotaExceeded
+		}
+	}
+
+	return m.ledger.AddUpload(ctx, owner, Info{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     time.Now(),
+	})
+}
+
+// Delete removes the object for all of its owners.
+func (m *Metered) Delete(ctx context.Context, key string) error {
+	if err := m.Blob.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	return m.ledger.RemoveUpload(ctx, key)
+}
+
+// GetRange keeps range reads of the wrapped store available.
+func (m *Metered) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if ranged, ok := m.Blob.(RangeReader); ok {
+		return ranged.GetRange(ctx, key, offset, length)
+	}
+
+	r, err := m.Blob.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return r, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(r, length), r}, nil
+}
+
+// List enumerates the objects of the wrapped store, if it can.
+func (m *Metered) List(ctx context.Context, prefix string, fn func(info Info) error) error {
+	lister, ok := m.Blob.(Lister)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+
+	return lister.List(ctx, prefix, fn)
+}
+
+// Verify checks signed URLs of the wrapped store, if it serves them.
+func (m *Metered) Verify(key string, expires string, signature string, now time.Time) (time.Time, error) {
+	verifier, ok := m.Blob.(Verifier)
+	if !ok {
+		return time.Time{}, ErrInvalidSignature
+	}
+
+	return verifier.Verify(key, expires, signature, now)
+}
+
+// countingReader counts the bytes read and fails once more than limit
+// bytes were read. A negative limit is unlimited.
+type countingReader struct {
+	r        io.Reader
+	n        int64
+	limit    int64
+	exceeded bool
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.limit >= 0 && r.n > r.limit {
+		r.exceeded = true
+		return n, ErrQuotaExceeded
+	}
+	return n, err
+}
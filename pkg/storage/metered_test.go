@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// memoryLedger maps owners to the objects they hold.
+type memoryLedger map[string]map[string]Info
+
+func (l memoryLedger) UploadedBytes(ctx context.Context, owner string) (int64, error) {
+	var total int64
+	for _, info := range l[owner] {
+		total += info.Size
+	}
+	return total, nil
+}
+
+func (l memoryLedger) HasUpload(ctx context.Context, owner string, key string) (bool, error) {
+	_, ok := l[owner][key]
+	return ok, nil
+}
+
+func (l memoryLedger) AddUpload(ctx context.Context, owner string, info Info) error {
+	if l[owner] == nil {
+		l[owner] = map[string]Info{}
+	}
+	l[owner][info.Key] = info
+	return nil
+}
+
+func (l memoryLedger) RemoveUpload(ctx context.Context, key string) error {
+	for owner, objects := range l {
+		delete(objects, key)
+		if len(objects) == 0 {
+			delete(l, owner)
+		}
+	}
+	return nil
+}
+
+func TestMetered(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "/api/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := memoryLedger{}
+	store := NewMetered(local, ledger, 10)
+	ctx := WithOwner(context.Background(), "user")
+
+	if err := store.Put(ctx, "a.txt", strings.NewReader("123456"), PutOptions{ContentType: "text/plain"}); err != nil {
+		t.Fatal(err)
+	}
+	if info := ledger["user"]["a.txt"]; info.Size != 6 || info.ContentType != "text/plain" {
+		t.Errorf("ledger = %+v", ledger)
+	}
+
+	if err := store.Put(ctx, "b.txt", strings.NewReader("123456"), PutOptions{}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Put over quota = %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := local.Stat(ctx, "b.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("object over quota was stored: %v", err)
+	}
+
+	if err := store.Put(context.Background(), "c.txt", strings.NewReader("123456"), PutOptions{}); err != nil {
+		t.Errorf("Put without owner = %v", err)
+	}
+	if len(ledger) != 1 || len(ledger["user"]) != 1 {
+		t.Error("object without owner was recorded")
+	}
+
+	if err := store.Delete(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if len(ledger) != 0 {
+		t.Errorf("ledger after Delete = %+v", ledger)
+	}
+}
+
+func TestPutOnce(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "/api/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := memoryLedger{}
+	store := NewMetered(local, ledger, 10)
+	alice := WithOwner(context.Background(), "alice")
+	bob := WithOwner(context.Background(), "bob")
+
+	stored, err := PutOnce(alice, store, "a.txt", strings.NewReader("123456"), PutOptions{ContentType: "text/plain"})
+	if err != nil || !stored {
+		t.Fatalf("first PutOnce = %v, %v", stored, err)
+	}
+
+	// the content is not read again, nor counted twice for alice
+	for _, ctx := range []context.Context{alice, bob} {
+		stored, err := PutOnce(ctx, store, "a.txt", iotest.ErrReader(errors.New("read")), PutOptions{})
+		if err != nil || stored {
+			t.Errorf("PutOnce of a stored object = %v, %v", stored, err)
+		}
+	}
+	if used, _ := ledger.UploadedBytes(alice, "alice"); used != 6 {
+		t.Errorf("alice uses %d bytes, want 6", used)
+	}
+	if info := ledger["bob"]["a.txt"]; info.Size != 6 {
+		t.Errorf("ledger of bob = %+v", ledger["bob"])
+	}
+
+	if err := store.Put(bob, "b.txt", strings.NewReader("1234"), PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Link(bob, "a.txt"); err != nil {
+		t.Errorf("Link of a held object = %v", err)
+	}
+	if err := store.Link(WithOwner(context.Background(), "carol"), "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(alice, "c.txt", strings.NewReader("12"), PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PutOnce(alice, store, "b.txt", strings.NewReader("1234"), PutOptions{}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Link over quota = %v, want ErrQuotaExceeded", err)
+	}
+	if err := store.Link(alice, "missing.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Link of a missing object = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(alice, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ledger["bob"]["a.txt"]; ok {
+		t.Error("Delete kept the records of other owners")
+	}
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header  string
+		offset  int64
+		length  int64
+		partial bool
+		err     error
+	}{
+		{"", 0, 100, false, nil},
+		{"bytes=0-9", 0, 10, true, nil},
+		{"bytes=90-", 90, 10, true, nil},
+		{"bytes=90-200", 90, 10, true, nil},
+		{"bytes=-20", 80, 20, true, nil},
+		{"bytes=-200", 0, 100, true, nil},
+		{"bytes=100-", 0, 0, false, ErrUnsatisfiableRange},
+		{"bytes=-0", 0, 0, false, ErrUnsatisfiableRange},
+		{"bytes=0-1,5-6", 0, 100, false, nil},
+		{"bytes=5-1", 0, 100, false, nil},
+		{"items=0-1", 0, 100, false, nil},
+		{"bytes=abc", 0, 100, false, nil},
+	}
+
+	for _, test := range tests {
+		offset, length, partial, err := ParseRange(test.header, 100)
+		if offset != test.offset || length != test.length || partial != test.partial || !errors.Is(err, test.err) {
+			t.Errorf("ParseRange(%q) = %d, %d, %v, %v, want %d, %d, %v, %v",
+				test.header, offset, length, partial, err, test.offset, test.length, test.partial, test.err)
+		}
+	}
+}
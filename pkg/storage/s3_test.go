@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a MinIO-style stand-in: a single-bucket object store that
+// checks the signature of every request.
+type fakeS3 struct {
+	t      *testing.T
+	signer signer
+
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{
+		t:            t,
+		signer:       signer{accessKey: "minio", secretKey: "minio123", region: "us-east-1", service: "s3"},
+		objects:      make(map[string][]byte),
+		contentTypes: make(map[string]string),
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, server
+}
+
+func (f *fakeS3) verify(r *http.Request) bool {
+	now, err := time.Parse(sigV4TimeFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+
+	auth := r.Header.Get("Authorization")
+	start := strings.Index(auth, "SignedHeaders=")
+	end := strings.Index(auth, ", Signature=")
+	if !strings.HasPrefix(auth, sigV4Algorithm+" Credential=minio/") || start < 0 || end < start {
+		return false
+	}
+	headers := strings.Split(auth[start+len("SignedHeaders="):end], ";")
+
+	canonical := canonicalRequest(r, headers, r.URL.Query(), r.Header.Get("X-Amz-Content-Sha256"))
+	return auth[end+len(", Signature="):] == f.signer.signature(now, canonical)
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.verify(r) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+		f.list(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if r.ContentLength != int64(len(body)) {
+			f.t.Errorf("PUT %s: Content-Length %d, body %d bytes", key, r.ContentLength, len(body))
+		}
+		f.objects[key] = body
+		f.contentTypes[key] = r.Header.Get("Content-Type")
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", f.contentTypes[key])
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(body)))
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// list answers ListObjectsV2 with one object per page.
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Path + "/" + r.URL.Query().Get("prefix")
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if token := r.URL.Query().Get("continuation-token"); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+
+	fmt.Fprint(w, "<ListBucketResult>")
+	if start < len(keys) {
+		key := strings.TrimPrefix(keys[start], r.URL.Path+"/")
+		fmt.Fprintf(w, "<Contents><Key>%s</Key><LastModified>2024-01-02T03:04:05.000Z</LastModified><ETag>&quot;etag&quot;</ETag><Size>%d</Size></Contents>", key, len(f.objects[keys[start]]))
+	}
+	if start+1 < len(keys) {
+		fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", start+1)
+	}
+	fmt.Fprint(w, "</ListBucketResult>")
+}
+
+func TestS3(t *testing.T) {
+	fake, server := newFakeS3(t)
+
+	store, err := NewS3(S3Config{Endpoint: server.URL, Bucket: "todos", AccessKey: "minio", SecretKey: "minio123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "variants/a b/thumb.jpg", strings.NewReader("hello world"), PutOptions{ContentType: "image/jpeg"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["/todos/variants/a b/thumb.jpg"]; !ok {
+		t.Fatalf("object not stored path-style, have %v", fake.objects)
+	}
+
+	info, err := store.Stat(ctx, "variants/a b/thumb.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 11 || info.ContentType != "image/jpeg" || info.ETag != `"etag"` {
+		t.Errorf("Stat = %+v", info)
+	}
+
+	r, err := store.GetRange(ctx, "variants/a b/thumb.jpg", 6, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(r); string(body) != "world" {
+		t.Errorf("GetRange = %q, want world", body)
+	}
+	r.Close()
+
+	if err := store.Delete(ctx, "variants/a b/thumb.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "variants/a b/thumb.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Stat(ctx, "missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of missing object = %v, want ErrNotFound", err)
+	}
+
+	wrong, _ := NewS3(S3Config{Endpoint: server.URL, Bucket: "todos", AccessKey: "minio", SecretKey: "wrong"})
+	if err := wrong.Put(ctx, "a.jpg", strings.NewReader("x"), PutOptions{}); err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("Put with wrong secret = %v, want signature error", err)
+	}
+
+	if _, err := store.Get(ctx, "../escape"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Get of escaping key = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestS3List(t *testing.T) {
+	_, server := newFakeS3(t)
+
+	store, _ := NewS3(S3Config{Endpoint: server.URL, Bucket: "todos", AccessKey: "minio", SecretKey: "minio123"})
+	ctx := context.Background()
+
+	for _, key := range []string{"a.jpg", "variants/a/thumb.jpg", "variants/a/medium.jpg", "b.png"} {
+		if err := store.Put(ctx, key, strings.NewReader("hello"), PutOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keys []string
+	err := store.List(ctx, "variants/", func(info Info) error {
+		if info.Size != 5 || info.ModTime.IsZero() {
+			t.Errorf("List info = %+v", info)
+		}
+		keys = append(keys, info.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "variants/a/medium.jpg,variants/a/thumb.jpg" {
+		t.Errorf("List = %v", keys)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = store.List(ctx, "", func(info Info) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("List stopped with %v after %d calls", err, calls)
+	}
+}
+
+func TestS3SignedURL(t *testing.T) {
+	store, _ := NewS3(S3Config{Endpoint: "http://localhost:9000", Bucket: "todos", AccessKey: "minio", SecretKey: "minio123"})
+	store.now = func() time.Time { return exampleTime }
+
+	url, err := store.SignedURL(context.Background(), "a.jpg", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(url, "http://localhost:9000/todos/a.jpg?") ||
+		!strings.Contains(url, "X-Amz-Expires=3600") || !strings.Contains(url, "X-Amz-Signature=") {
+		t.Errorf("SignedURL = %s", url)
+	}
+}
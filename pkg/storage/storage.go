@@ -0,0 +1,125 @@
+// Package storage keeps uploaded files in a blob store: the local disk or
+// any service speaking the S3 API, such as S3, MinIO or Google Cloud
+// Storage through its interoperability endpoint.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("blob not found")
+	ErrInvalidKey       = errors.New("invalid blob key")
+	ErrInvalidSignature = errors.New("invalid URL signature")
+	ErrExpired          = errors.New("signed URL expired")
+)
+
+// Blob stores objects under slash-separated keys such as "a1b2.jpg" or
+// "variants/a1b2/thumb.jpg".
+type Blob interface {
+	// Put stores the content of r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, options PutOptions) error
+	// Get opens the object. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (*Info, error)
+	// SignedURL returns a URL that grants read access to the object
+	// without further authentication until expiry has passed.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// RangeReader is implemented by drivers that can read part of an object.
+type RangeReader interface {
+	// GetRange opens length bytes of the object starting at offset. A
+	// negative length reads to the end.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+}
+
+// Verifier is implemented by drivers whose signed URLs are served by the
+// application rather than by the storage service.
+type Verifier interface {
+	// Verify checks the expires and signature query parameters of a signed
+	// URL for key and returns when the URL expires.
+	Verify(key string, expires string, signature string, now time.Time) (time.Time, error)
+}
+
+// Linker is implemented by stores that record the owners of objects.
+type Linker interface {
+	// Link records the owner of ctx, see WithOwner, as a holder of the
+	// stored object. It fails with ErrNotFound if there is no such object.
+	Link(ctx context.Context, key string) error
+}
+
+// Lister is implemented by drivers that can enumerate their objects.
+type Lister interface {
+	// List calls fn for every object whose key starts with prefix, in no
+	// particular order, until fn returns an error.
+	List(ctx context.Context, prefix string, fn func(info Info) error) error
+}
+
+type PutOptions struct {
+	ContentType string
+}
+
+type Info struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	ETag        string
+}
+
+// CleanKey normalises a key and rejects keys that would escape the store,
+// such as "../etc/passwd".
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+
+	return cleaned, nil
+}
+
+// Copy copies the object at src to dst within the store.
+func Copy(ctx context.Context, blob Blob, src, dst string) error {
+	info, err := blob.Stat(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	r, err := blob.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return blob.Put(ctx, dst, r, PutOptions{ContentType: info.ContentType})
+}
+
+// PutOnce stores content under a key derived from it, such as its hash,
+// unless the store has the key already; a Linker then links the owner to
+// the existing object instead. r is only read if the content is stored,
+// which PutOnce reports.
+func PutOnce(ctx context.Context, blob Blob, key string, r io.Reader, options PutOptions) (bool, error) {
+	var err error
+	if linker, ok := blob.(Linker); ok {
+		err = linker.Link(ctx, key)
+	} else {
+		_, err = blob.Stat(ctx, key)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+
+	return true, blob.Put(ctx, key, r, options)
+}
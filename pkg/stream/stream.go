@@ -0,0 +1,287 @@
+// Package stream fans events out to long-lived client connections such as
+// WebSockets. Events are addressed to an audience, e.g. a user ID, and the
+// most recent ones are kept so a client that reconnects can resume after
+// the last event it received.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClosed     = errors.New("stream hub closed")
+	ErrSlowClient = errors.New("client too slow to keep up")
+)
+
+// Message is an event as sent to clients. IDs increase over the lifetime
+// of the hub and, since the first ID is taken from the clock, also across
+// restarts, so an ID from before a restart is simply older than the history.
+type Message struct {
+	ID        uint64          `json:"-"`
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+
+	audience string
+}
+
+// EventID returns the ID in the form clients send back to resume.
+func (m Message) EventID() string {
+	return strconv.FormatUint(m.ID, 10)
+}
+
+// MarshalJSON includes the ID as a string, as JavaScript numbers cannot
+// hold every uint64.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	return json.Marshal(struct {
+		ID string `json:"id"`
+		message
+	}{m.EventID(), message(m)})
+}
+
+// ParseEventID parses an ID sent by a client. Empty or invalid IDs are 0,
+// which resumes nothing.
+func ParseEventID(id string) uint64 {
+	parsed, _ := strconv.ParseUint(id, 10, 64)
+	return parsed
+}
+
+type Options struct {
+	// HistorySize is the number of recent messages, over all audiences,
+	// that clients can resume from.
+	HistorySize int
+	// BufferSize is the number of messages queued per client. A client
+	// that falls further behind is disconnected, see Client.Done.
+	BufferSize int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		HistorySize: 1000,
+		BufferSize:  64,
+	}
+}
+
+type Hub struct {
+	options Options
+
+	mu      sync.Mutex
+	nextID  uint64
+	history []Message
+	start   int
+	clients map[string]map[*Client]struct{}
+	closed  bool
+}
+
+func NewHub(options Options) *Hub {
+	return &Hub{
+		options: options,
+		nextID:  uint64(time.Now().UnixMicro()),
+		history: make([]Message, 0, options.HistorySize),
+		clients: make(map[string]map[*Client]struct{}),
+	}
+}
+
+// Publish sends a message to every client of the audience and adds it to
+// the history. Clients whose buffer is full are disconnected rather than
+// holding up the others.
+func (h *Hub) Publish(audience string, eventType string, data json.RawMessage) Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	message := Message{
+		ID:        h.nextID,
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Data:      data,
+		audience:  audience,
+	}
+	h.nextID++
+
+	h.remember(message)
+
+	for client := range h.clients[audience] {
+		if !client.accepts(message.Type) {
+			continue
+		}
+
+		select {
+		case client.messages <- message:
+		default:
+			h.drop(client, ErrSlowClient)
+		}
+	}
+
+	return message
+}
+
+func (h *Hub) remember(message Message) {
+	if h.options.HistorySize <= 0 {
+		return
+	}
+
+	if len(h.history) < h.options.HistorySize {
+		h.history = append(h.history, message)
+		return
+	}
+
+	h.history[h.start] = message
+	h.start = (h.start + 1) % len(h.history)
+}
+
+// Subscribe registers a client for the audience. Messages of the types
+// accepted by filter are delivered; a nil filter accepts all. With a
+// lastID the client first receives the messages it missed; when some of
+// them are no longer in the history, Resumed reports false and the client
+// should reload its state instead.
+func (h *Hub) Subscribe(audience string, lastID uint64, filter func(eventType string) bool) (*Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	client := &Client{
+		hub:      h,
+		audience: audience,
+		filter:   filter,
+		resumed:  true,
+		done:     make(chan struct{}),
+	}
+
+	var missed []Message
+	if lastID > 0 {
+		missed, client.resumed = h.since(lastID)
+	}
+
+	var replay []Message
+	for _, message := range missed {
+		if message.audience == audience && client.accepts(message.Type) {
+			replay = append(replay, message)
+		}
+	}
+
+	// the replay must fit ahead of new messages
+	size := h.options.BufferSize
+	if len(replay) > size {
+		size = len(replay)
+	}
+	client.messages = make(chan Message, size)
+	for _, message := range replay {
+		client.messages <- message
+	}
+
+	if h.clients[audience] == nil {
+		h.clients[audience] = make(map[*Client]struct{})
+	}
+	h.clients[audience][client] = struct{}{}
+
+	return client, nil
+}
+
+// since returns the messages after lastID and whether the history reaches
+// back far enough to hold all of them.
+func (h *Hub) since(lastID uint64) ([]Message, bool) {
+	if lastID >= h.nextID {
+		// an ID from the future was not issued by this hub
+		return nil, false
+	}
+	if len(h.history) == 0 {
+		return nil, lastID == h.nextID-1
+	}
+
+	var missed []Message
+	for i := range h.history {
+		message := h.history[(h.start+i)%len(h.history)]
+		if message.ID > lastID {
+			missed = append(missed, message)
+		}
+	}
+
+	// IDs are consecutive, so nothing is missing when the oldest message
+	// kept directly follows lastID or is older
+	return missed, h.history[h.start].ID <= lastID+1
+}
+
+func (h *Hub) drop(client *Client, reason error) {
+	clients := h.clients[client.audience]
+	if _, ok := clients[client]; !ok {
+		return
+	}
+
+	delete(clients, client)
+	if len(clients) == 0 {
+		delete(h.clients, client.audience)
+	}
+
+	client.err = reason
+	close(client.done)
+}
+
+// Close disconnects every client. Later subscriptions fail with ErrClosed.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, clients := range h.clients {
+		for client := range clients {
+			h.drop(client, ErrClosed)
+		}
+	}
+}
+
+// Client is a subscription of one connection.
+type Client struct {
+	hub      *Hub
+	audience string
+	filter   func(eventType string) bool
+	resumed  bool
+	messages chan Message
+	done     chan struct{}
+	err      error
+}
+
+func (c *Client) accepts(eventType string) bool {
+	return c.filter == nil || c.filter(eventType)
+}
+
+// Messages delivers the client's messages. It is never closed, select on
+// Done as well.
+func (c *Client) Messages() <-chan Message {
+	return c.messages
+}
+
+// Done is closed when the hub disconnects the client, see Err.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the client was disconnected: ErrSlowClient when it fell
+// behind by more than the buffer, ErrClosed when the hub was closed.
+func (c *Client) Err() error {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+
+	return c.err
+}
+
+// Resumed reports whether every message since the requested last ID was
+// replayed.
+func (c *Client) Resumed() bool {
+	return c.resumed
+}
+
+// Close unsubscribes the client.
+func (c *Client) Close() {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+
+	c.hub.drop(c, nil)
+}
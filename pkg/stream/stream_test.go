@@ -0,0 +1,132 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func receive(t *testing.T, client *Client, n int) []Message {
+	t.Helper()
+
+	messages := make([]Message, 0, n)
+	for i := 0; i < n; i++ {
+		select {
+		case message := <-client.Messages():
+			messages = append(messages, message)
+		default:
+			t.Fatalf("received %d messages, want %d", len(messages), n)
+		}
+	}
+
+	select {
+	case message := <-client.Messages():
+		t.Fatalf("unexpected message %s", message.Type)
+	default:
+	}
+
+	return messages
+}
+
+func TestPublishToAudience(t *testing.T) {
+	hub := NewHub(DefaultOptions())
+
+	alice, _ := hub.Subscribe("alice", 0, nil)
+	bob, _ := hub.Subscribe("bob", 0, nil)
+	created, _ := hub.Subscribe("alice", 0, func(eventType string) bool { return eventType == "todo.created" })
+
+	hub.Publish("alice", "todo.created", json.RawMessage(`{}`))
+	hub.Publish("alice", "todo.deleted", json.RawMessage(`{}`))
+
+	if got := receive(t, alice, 2); got[0].Type != "todo.created" || got[1].Type != "todo.deleted" || got[1].ID != got[0].ID+1 {
+		t.Errorf("alice received %v", got)
+	}
+	receive(t, bob, 0)
+	receive(t, created, 1)
+}
+
+func TestResume(t *testing.T) {
+	hub := NewHub(Options{HistorySize: 3, BufferSize: 8})
+
+	first := hub.Publish("alice", "todo.created", nil)
+	hub.Publish("bob", "todo.created", nil)
+	third := hub.Publish("alice", "todo.updated", nil)
+
+	client, _ := hub.Subscribe("alice", first.ID, nil)
+	if got := receive(t, client, 1); got[0].ID != third.ID || !client.Resumed() {
+		t.Errorf("resume replayed %v, resumed %v", got, client.Resumed())
+	}
+
+	client, _ = hub.Subscribe("alice", third.ID, nil)
+	if receive(t, client, 0); !client.Resumed() {
+		t.Errorf("resume from the latest ID not complete")
+	}
+
+	// push the first message out of the history
+	hub.Publish("alice", "todo.deleted", nil)
+	hub.Publish("alice", "todo.deleted", nil)
+
+	client, _ = hub.Subscribe("alice", first.ID, nil)
+	if receive(t, client, 3); client.Resumed() {
+		t.Errorf("resume past the history reported complete")
+	}
+
+	client, _ = hub.Subscribe("alice", first.ID+100, nil)
+	if receive(t, client, 0); client.Resumed() {
+		t.Errorf("resume from an unknown ID reported complete")
+	}
+}
+
+func TestSlowClient(t *testing.T) {
+	hub := NewHub(Options{HistorySize: 10, BufferSize: 2})
+
+	slow, _ := hub.Subscribe("alice", 0, nil)
+	for i := 0; i < 3; i++ {
+		hub.Publish("alice", "todo.created", nil)
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow client was not disconnected")
+	}
+	if !errors.Is(slow.Err(), ErrSlowClient) {
+		t.Errorf("Err = %v, want ErrSlowClient", slow.Err())
+	}
+
+	// reconnecting resumes where it left off
+	received := receive(t, slow, 2)
+	client, _ := hub.Subscribe("alice", received[1].ID, nil)
+	receive(t, client, 1)
+}
+
+func TestClose(t *testing.T) {
+	hub := NewHub(DefaultOptions())
+
+	client, _ := hub.Subscribe("alice", 0, nil)
+	hub.Close()
+
+	<-client.Done()
+	if !errors.Is(client.Err(), ErrClosed) {
+		t.Errorf("Err = %v, want ErrClosed", client.Err())
+	}
+	if _, err := hub.Subscribe("alice", 0, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestMessageJSON(t *testing.T) {
+	message := Message{ID: 1700000000000001, Type: "todo.created", Data: json.RawMessage(`{"title":"a"}`)}
+
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"id":"1700000000000001"`) || !strings.Contains(string(encoded), `"data":{"title":"a"}`) {
+		t.Errorf("Marshal = %s", encoded)
+	}
+	if ParseEventID(message.EventID()) != message.ID || ParseEventID("nope") != 0 {
+		t.Errorf("ParseEventID does not round-trip")
+	}
+}
@@ -0,0 +1,89 @@
+// Package tus handles the headers of the tus resumable upload protocol,
+// version 1.0.0, see https://tus.io/protocols/resumable-upload.
+package tus
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Version = "1.0.0"
+	// Extensions lists the protocol extensions the server supports.
+	Extensions = "creation,expiration,termination"
+	// ContentType is the content type of PATCH requests.
+	ContentType = "application/offset+octet-stream"
+)
+
+const (
+	HeaderResumable = "Tus-Resumable"
+	HeaderVersion   = "Tus-Version"
+	HeaderExtension = "Tus-Extension"
+	HeaderMaxSize   = "Tus-Max-Size"
+	HeaderOffset    = "Upload-Offset"
+	HeaderLength    = "Upload-Length"
+	HeaderMetadata  = "Upload-Metadata"
+	HeaderExpires   = "Upload-Expires"
+
+	// HeaderMaxChunkSize is not part of the protocol, it announces the
+	// largest PATCH request the server accepts.
+	HeaderMaxChunkSize = "Tus-Max-Chunk-Size"
+)
+
+var (
+	ErrInvalidMetadata = errors.New("invalid Upload-Metadata")
+	ErrInvalidSize     = errors.New("invalid size")
+)
+
+// ParseMetadata decodes an Upload-Metadata header: comma-separated pairs of
+// a key and a base64 value, such as "filename d29ybGQ=,private". Keys may
+// come without a value.
+func ParseMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		if key == "" || strings.ContainsAny(encoded, " ") {
+			return nil, ErrInvalidMetadata
+		}
+		if _, ok := metadata[key]; ok {
+			return nil, ErrInvalidMetadata
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidMetadata
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+// ParseSize parses the non-negative integer of an Upload-Length or
+// Upload-Offset header.
+func ParseSize(value string) (int64, error) {
+	if value == "" || strings.TrimLeft(value, "0123456789") != "" {
+		return 0, ErrInvalidSize
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidSize
+	}
+
+	return size, nil
+}
+
+// FormatExpires formats an Upload-Expires header.
+func FormatExpires(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
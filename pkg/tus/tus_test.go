@@ -0,0 +1,52 @@
+package tus
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := ParseMetadata("filename cmVwb3J0LnBkZg==, filetype YXBwbGljYXRpb24vcGRm,private")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata["filename"] != "report.pdf" || metadata["filetype"] != "application/pdf" {
+		t.Errorf("ParseMetadata = %v", metadata)
+	}
+	if value, ok := metadata["private"]; !ok || value != "" {
+		t.Errorf("key without value = %q, %v", value, ok)
+	}
+
+	if metadata, err := ParseMetadata(""); err != nil || len(metadata) != 0 {
+		t.Errorf("ParseMetadata of empty header = %v, %v", metadata, err)
+	}
+
+	for _, header := range []string{"filename not-base64!", "a YQ==,a Yg==", "a YQ== Yg=="} {
+		if _, err := ParseMetadata(header); !errors.Is(err, ErrInvalidMetadata) {
+			t.Errorf("ParseMetadata(%q) = %v, want ErrInvalidMetadata", header, err)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	if size, err := ParseSize("1024"); err != nil || size != 1024 {
+		t.Errorf("ParseSize(1024) = %d, %v", size, err)
+	}
+	if size, err := ParseSize("0"); err != nil || size != 0 {
+		t.Errorf("ParseSize(0) = %d, %v", size, err)
+	}
+
+	for _, value := range []string{"", "-1", "+1", "1.5", "abc", "99999999999999999999"} {
+		if _, err := ParseSize(value); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("ParseSize(%q) = %v, want ErrInvalidSize", value, err)
+		}
+	}
+}
+
+func TestFormatExpires(t *testing.T) {
+	expires := time.Date(2024, 1, 2, 10, 4, 5, 0, time.FixedZone("WIB", 7*60*60))
+	if got := FormatExpires(expires); got != "Tue, 02 Jan 2024 03:04:05 GMT" {
+		t.Errorf("FormatExpires = %q", got)
+	}
+}
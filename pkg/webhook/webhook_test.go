@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"todo.created"}`)
+	now := time.Unix(1700000000, 0)
+
+	header := http.Header{}
+	header.Set(HeaderTimestamp, "1700000000")
+	header.Set(HeaderSignature, Sign("secret", now.Unix(), body))
+
+	if err := Verify("secret", header, body, 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Errorf("Verify = %v, want nil", err)
+	}
+	if err := Verify("other", header, body, 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with wrong secret = %v, want ErrInvalidSignature", err)
+	}
+	if err := Verify("secret", header, []byte(`{"type":"todo.deleted"}`), 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of tampered body = %v, want ErrInvalidSignature", err)
+	}
+	if err := Verify("secret", header, body, 5*time.Minute, now.Add(10*time.Minute)); !errors.Is(err, ErrExpiredTimestamp) {
+		t.Errorf("Verify of old request = %v, want ErrExpiredTimestamp", err)
+	}
+
+	// the timestamp is covered by the signature
+	header.Set(HeaderTimestamp, "1700000600")
+	if err := Verify("secret", header, body, 5*time.Minute, now.Add(10*time.Minute)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with replaced timestamp = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// loopback lets the tests reach their httptest servers.
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func TestSend(t *testing.T) {
+	body := []byte(`{"type":"todo.created"}`)
+
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		got, _ := io.ReadAll(r.Body)
+		if err := Verify("secret", r.Header, got, time.Minute, time.Now()); err != nil {
+			t.Errorf("server: Verify = %v", err)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	response, err := NewSender(time.Second, loopback).Send(context.Background(), Request{
+		URL:        server.URL,
+		Secret:     "secret",
+		Event:      "todo.created",
+		DeliveryID: "delivery-1",
+		Body:       body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK || response.Body != "ok" {
+		t.Errorf("response = %d %q, want 200 \"ok\"", response.StatusCode, response.Body)
+	}
+	if received.Get(HeaderEvent) != "todo.created" || received.Get(HeaderDelivery) != "delivery-1" {
+		t.Errorf("headers = %v, want event and delivery ID", received)
+	}
+	if received.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", received.Get("Content-Type"))
+	}
+}
+
+func TestSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(strings.Repeat("x", MaxResponseBody+100)))
+		case "/redirect":
+			http.Redirect(w, r, "/", http.StatusFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewSender(50*time.Millisecond, loopback)
+
+	response, err := sender.Send(context.Background(), Request{URL: server.URL + "/error"})
+	var statusError *StatusError
+	if !errors.As(err, &statusError) || statusError.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Send to failing endpoint = %v, want StatusError 500", err)
+	}
+	if response == nil || len(response.Body) != MaxResponseBody {
+		t.Errorf("response body not kept or not cut off: %+v", response)
+	}
+
+	if _, err := sender.Send(context.Background(), Request{URL: server.URL + "/redirect"}); !errors.As(err, &statusError) || statusError.StatusCode != http.StatusFound {
+		t.Errorf("Send to redirect = %v, want StatusError 302", err)
+	}
+
+	response, err = sender.Send(context.Background(), Request{URL: server.URL + "/slow"})
+	if err == nil || response != nil {
+		t.Errorf("Send to slow endpoint = %v, %v, want timeout", response, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{20, time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := Backoff(test.attempts, 10*time.Second, time.Hour); got != test.want {
+			t.Errorf("Backoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestSendPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback endpoint")
+	}))
+	defer server.Close()
+
+	_, err := NewSender(time.Second, nil).Send(context.Background(), Request{URL: server.URL})
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("Send to loopback = %v, want ErrPrivateAddress", err)
+	}
+
+	for _, address := range []string{"10.1.2.3:80", "169.254.169.254:80", "[::ffff:192.168.0.1]:80", "[fe80::1]:443", "100.64.0.1:80"} {
+		if err := checkAddress(address, nil); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("checkAddress(%s) = %v, want ErrPrivateAddress", address, err)
+		}
+	}
+	if err := checkAddress("93.184.216.34:443", nil); err != nil {
+		t.Errorf("checkAddress of a public address = %v", err)
+	}
+	allowed, _ := ParseNetworks("10.0.0.0/8")
+	if err := checkAddress("10.1.2.3:80", allowed); err != nil {
+		t.Errorf("checkAddress of an allowed address = %v", err)
+	}
+}